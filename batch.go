@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const recursivePattern = "/..."
+
+type BatchTarget struct {
+	Filename   string
+	StructName string
+	OutputDir  string
+	Package    string
+	Replace    bool
+	HTML       bool
+	Release    string
+}
+
+type Batch struct {
+	Patterns  []string
+	OutputDir string
+	Package   string
+	Replace   bool
+	Check     bool
+	Release   string
+	HTML      bool
+}
+
+// FindTargets walks the patterns (files, directories or "dir/..." trees) and
+// returns every struct that has at least one version tag.
+func (b *Batch) FindTargets() ([]BatchTarget, error) {
+	var files []string
+	for _, pattern := range b.Patterns {
+		matched, err := b.expandPattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matched...)
+	}
+	sort.Strings(files)
+
+	var targets []BatchTarget
+	seen := make(map[string]bool)
+	for _, file := range files {
+		if seen[file] {
+			continue
+		}
+		seen[file] = true
+
+		structs, err := b.versionedStructs(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, structName := range structs {
+			targets = append(targets, BatchTarget{
+				Filename:   file,
+				StructName: structName,
+				OutputDir:  b.OutputDir,
+				Package:    b.Package,
+				Replace:    b.Replace,
+				HTML:       b.HTML,
+				Release:    b.Release,
+			})
+		}
+	}
+
+	return targets, nil
+}
+
+func (b *Batch) expandPattern(pattern string) ([]string, error) {
+	recursive := pattern == "..." || strings.HasSuffix(pattern, recursivePattern)
+	root := strings.TrimSuffix(strings.TrimSuffix(pattern, "..."), "/")
+	if root == "" {
+		root = "."
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{root}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path == root {
+				return nil
+			}
+			name := entry.Name()
+			if !recursive || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" || name == "vendor" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) == ".go" && !strings.HasSuffix(path, "_test.go") {
+			files = append(files, path)
+		}
+		return nil
+	})
+
+	return files, err
+}
+
+func (b *Batch) versionedStructs(file string) ([]string, error) {
+	node, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	format := &Format{}
+	var structs []string
+	for _, decl := range node.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+
+			structType, ok := typeSpec.Type.(*ast.StructType)
+			if ok && format.HasVersionTags(structType) {
+				structs = append(structs, typeSpec.Name.Name)
+			}
+		}
+	}
+
+	return structs, nil
+}
+
+// Run generates every struct found in the patterns.
+func (b *Batch) Run() ([]StaleFile, error) {
+	targets, err := b.FindTargets()
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no versioned structs found in %s", strings.Join(b.Patterns, ", "))
+	}
+
+	// Structs are released one by one, they rarely share their versions
+	if b.Release != "" && len(targets) > 1 {
+		return nil, fmt.Errorf("--release found %d versioned structs in %s, release them one by one with -f and -s, or per model in %s", len(targets), strings.Join(b.Patterns, ", "), ConfigFileName)
+	}
+
+	return b.Generate(targets)
+}
+
+// Generate generates the hub and eras of every target and writes the types.go
+// registry once per output directory, after all hubs are in place. In check
+// mode nothing is written and the stale files of every target are returned.
+func (b *Batch) Generate(targets []BatchTarget) ([]StaleFile, error) {
+	hubs := make(map[string]string)
+	output := &Output{}
+	var generators, typesGenerators []*Generator
+	for _, target := range targets {
+		generator := NewGenerator(target.Filename, target.StructName, target.OutputDir, target.Package, target.Replace)
+		generator.SkipTypes = true
+		generator.Check = b.Check
+		generator.Output = output
+		generator.Release = target.Release
+		generator.HTML = target.HTML || b.HTML
+
+		hubPath := filepath.Join(generator.OutputDir, generator.Package, generator.StructName.Snake)
+		if previous, ok := hubs[hubPath]; ok {
+			return nil, fmt.Errorf("struct '%s' in '%s' and '%s' would generate the same hub '%s.go'", target.StructName, previous, target.Filename, hubPath)
+		}
+		hubs[hubPath] = target.Filename
+
+		if typesGenerator := b.typesGenerator(typesGenerators, generator); typesGenerator != nil {
+			typesGenerator.Hubs = append(typesGenerator.Hubs, generator.StructName)
+		} else {
+			typesGenerators = append(typesGenerators, generator)
+		}
+		generators = append(generators, generator)
+	}
+
+	// Malformed version tags of every struct are reported before anything is generated
+	var tagErrors VersionTagErrors
+	for _, generator := range generators {
+		err := generator.Validate()
+		if errs, ok := err.(VersionTagErrors); ok {
+			tagErrors = append(tagErrors, errs...)
+		} else if err != nil {
+			return nil, fmt.Errorf("%s (%s): %w", generator.StructName.Original, generator.Filename, err)
+		}
+	}
+	if len(tagErrors) > 0 {
+		return nil, tagErrors
+	}
+
+	for _, generator := range generators {
+		if err := generator.VersionedStructs(); err != nil {
+			return nil, fmt.Errorf("%s (%s): %w", generator.StructName.Original, generator.Filename, err)
+		}
+		if !b.Check {
+			fmt.Printf("Versioned %s from %s\n", generator.StructName.Original, generator.Filename)
+		}
+	}
+
+	for _, generator := range typesGenerators {
+		if err := generator.TypesFile(""); err != nil {
+			return nil, err
+		}
+	}
+
+	// Every hub, era and types.go of the run is written at once
+	if err := output.Flush(); err != nil {
+		return nil, err
+	}
+
+	var staleFiles []StaleFile
+	for _, generator := range generators {
+		staleFiles = append(staleFiles, generator.StaleFiles...)
+	}
+
+	return staleFiles, nil
+}
+
+func (b *Batch) typesGenerator(generators []*Generator, generator *Generator) *Generator {
+	for _, g := range generators {
+		if filepath.Clean(g.OutputDir) == filepath.Clean(generator.OutputDir) && g.Package == generator.Package {
+			return g
+		}
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeBatchFixture(t *testing.T, dir string) {
+	files := map[string]string{
+		"go.mod": goMod(t, "example.com/batch"),
+		"models/user.go": `package models
+
+type User struct {
+	Name  string
+	Email string ` + "`version:\"2-3\"`" + `
+}
+
+type Untagged struct {
+	Name string
+}
+`,
+		"models/admin/admin.go": `package admin
+
+type Admin struct {
+	Name  string
+	Level int ` + "`version:\"1-2\"`" + `
+}
+`,
+		"models/admin/admin_test.go": `package admin
+
+type Ignored struct {
+	Level int ` + "`version:\"1\"`" + `
+}
+`,
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestBatch_FindTargets(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	writeBatchFixture(t, tempDir)
+
+	tests := []struct {
+		name     string
+		patterns []string
+		expected []BatchTarget
+	}{
+		{
+			name:     "Recursive pattern",
+			patterns: []string{filepath.Join(tempDir, "models") + "/..."},
+			expected: []BatchTarget{
+				{Filename: filepath.Join(tempDir, "models", "admin", "admin.go"), StructName: "Admin"},
+				{Filename: filepath.Join(tempDir, "models", "user.go"), StructName: "User"},
+			},
+		},
+		{
+			name:     "Single directory",
+			patterns: []string{filepath.Join(tempDir, "models")},
+			expected: []BatchTarget{
+				{Filename: filepath.Join(tempDir, "models", "user.go"), StructName: "User"},
+			},
+		},
+		{
+			name:     "Single file",
+			patterns: []string{filepath.Join(tempDir, "models", "admin", "admin.go")},
+			expected: []BatchTarget{
+				{Filename: filepath.Join(tempDir, "models", "admin", "admin.go"), StructName: "Admin"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Batch{Patterns: tt.patterns}
+			targets, err := b.FindTargets()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, targets)
+		})
+	}
+}
+
+func TestBatch_Run(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	writeBatchFixture(t, tempDir)
+	outputDir := filepath.Join(tempDir, "generated")
+
+	b := &Batch{
+		Patterns:  []string{filepath.Join(tempDir, "models") + "/..."},
+		OutputDir: outputDir,
+		Package:   string(ModuleFolder),
+	}
+	staleFiles, err := b.Run()
+	assert.NoError(t, err)
+	assert.Empty(t, staleFiles)
+
+	for _, file := range []string{"user.go", "admin.go", "user/v1.go", "user/v2.go", "admin/v1.go", "admin/v2.go"} {
+		assert.FileExists(t, filepath.Join(outputDir, string(ModuleFolder), file))
+	}
+
+	types, err := os.ReadFile(filepath.Join(outputDir, string(ModuleFolder), "types.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(types), `TypeAdmin Type = "admin"`)
+	assert.Regexp(t, `TypeUser\s+Type = "user"`, string(types))
+
+	b.Check = true
+	staleFiles, err = b.Run()
+	assert.NoError(t, err)
+	assert.Empty(t, staleFiles)
+}
+
+func TestBatch_Run_Release(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	writeBatchFixture(t, tempDir)
+
+	// Structs of a tree don't share their versions, so they aren't released together
+	b := &Batch{
+		Patterns: []string{filepath.Join(tempDir, "models") + "/..."},
+		Package:  string(ModuleFolder),
+		Release:  "1",
+	}
+	_, err = b.Run()
+	assert.ErrorContains(t, err, "--release found 2 versioned structs in ")
+	assert.NoFileExists(t, filepath.Join(tempDir, LockFileName))
+
+	b.Patterns = []string{filepath.Join(tempDir, "models", "admin")}
+	_, err = b.Run()
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(tempDir, LockFileName))
+}
+
+func TestBatch_Run_NoTargets(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	b := &Batch{Patterns: []string{tempDir + "/..."}, Package: string(ModuleFolder)}
+	_, err = b.Run()
+	assert.Error(t, err)
+}
+
+func TestBatch_Run_InvalidTags(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	writeBatchFixture(t, tempDir)
+	invalid := "package models\n\ntype Broken struct {\n\tName string `version:\"3-1\"`\n}\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "models", "broken.go"), []byte(invalid), 0644))
+
+	b := &Batch{
+		Patterns: []string{filepath.Join(tempDir, "models") + "/..."},
+		Package:  string(ModuleFolder),
+	}
+	_, err = b.Run()
+	assert.IsType(t, VersionTagErrors{}, err)
+	assert.Contains(t, err.Error(), "broken.go:4:24: field Name")
+	assert.NoDirExists(t, filepath.Join(tempDir, "models", string(ModuleFolder)))
+}
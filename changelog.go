@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+)
+
+type ChangelogField struct {
+	Name string
+	Type string
+	Doc  string
+	In   []bool // One per version of the document
+}
+
+type ChangelogEntry struct {
+	Name     string
+	Type     string
+	Tag      string
+	Doc      string
+	Previous *ChangelogEntry // The declaration a changed field replaces
+}
+
+type ChangelogEra struct {
+	Version int
+	Added   []ChangelogEntry
+	Removed []ChangelogEntry
+	Changed []ChangelogEntry
+}
+
+type ChangelogTemplateData struct {
+	StructName StructName
+	Source     string
+	Versions   []int
+	Fields     []ChangelogField
+	Eras       []ChangelogEra
+}
+
+// ChangelogFile documents the fields of every version of the struct next to
+// its hub, in Markdown and, when requested, in HTML.
+func (g *Generator) ChangelogFile() error {
+	versionedDir := filepath.Join(g.OutputDir, g.Package)
+	data := g.changelog()
+
+	err := g.FileFromTemplate(GenerateFileFromTemplateInput{
+		TemplateFilePath: "changelog.md.tmpl",
+		OutputFilePath:   filepath.Join(versionedDir, fmt.Sprintf("%s.md", g.StructName.Snake)),
+		Source:           g.Source,
+		Data:             data,
+	})
+	if err != nil || !g.HTML {
+		return err
+	}
+
+	return g.FileFromTemplate(GenerateFileFromTemplateInput{
+		TemplateFilePath: "changelog.html.tmpl",
+		OutputFilePath:   filepath.Join(versionedDir, fmt.Sprintf("%s.html", g.StructName.Snake)),
+		Source:           g.Source,
+		Data:             data,
+	})
+}
+
+// changelog builds the field by version matrix, and what each version adds,
+// removes and changes from the previous one.
+func (g *Generator) changelog() ChangelogTemplateData {
+	versions := g.Format.SortedVersions
+	data := ChangelogTemplateData{
+		StructName: g.StructName,
+		Source:     g.SourceName,
+		Versions:   versions,
+	}
+
+	for _, field := range g.ProcessedFields {
+		row := ChangelogField{Name: field.Name, Doc: field.Doc}
+		for _, version := range versions {
+			eraField, ok := findField(g.VersionedFields[version], field.Name)
+			if ok && row.Type == "" {
+				row.Type = g.docType(eraField.Type)
+			}
+			row.In = append(row.In, ok)
+		}
+		data.Fields = append(data.Fields, row)
+	}
+
+	// The latest version goes first
+	for i := len(versions) - 1; i >= 0; i-- {
+		era := ChangelogEra{Version: versions[i]}
+		if i == 0 {
+			for _, field := range g.VersionedFields[versions[i]] {
+				era.Added = append(era.Added, g.changelogEntry(field))
+			}
+			data.Eras = append(data.Eras, era)
+			continue
+		}
+
+		removed, added, changed := diffEras(g.VersionedFields[versions[i-1]], g.VersionedFields[versions[i]])
+		for _, field := range added {
+			era.Added = append(era.Added, g.changelogEntry(field))
+		}
+		for _, field := range removed {
+			era.Removed = append(era.Removed, g.changelogEntry(field))
+		}
+		for _, pair := range changed {
+			entry, previous := g.changelogEntry(pair.to), g.changelogEntry(pair.from)
+			entry.Previous = &previous
+			era.Changed = append(era.Changed, entry)
+		}
+		data.Eras = append(data.Eras, era)
+	}
+
+	return data
+}
+
+func (g *Generator) changelogEntry(field HubFieldInfo) ChangelogEntry {
+	return ChangelogEntry{
+		Name: field.Name,
+		Type: g.docType(field.Type),
+		Tag:  field.Tag,
+		Doc:  field.Doc,
+	}
+}
+
+// docType shows the types declared next to the struct without the alias the
+// generated code imports them with.
+func (g *Generator) docType(fieldType string) string {
+	alias := regexp.MustCompile(`\b` + regexp.QuoteMeta(g.Format.sourceAlias()) + `\.`)
+	return alias.ReplaceAllString(fieldType, "")
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerator_ChangelogFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	fileName := filepath.Join(tempDir, "models", "user.go")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(fileName), os.ModePerm))
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte(goMod(t, "example.com/changelog")), 0644))
+	assert.NoError(t, os.WriteFile(fileName, []byte(`package models
+
+type Status string
+
+type User struct {
+	// Name of the user, as shown to others | not unique
+	Name   string `+"`json:\"name\"`"+`
+	Age    int32  `+"`json:\"age\" version:\"1\"`"+`
+	AgeV2  int64  `+"`json:\"age\" version:\"2+\"`"+` // Age in years
+	Email  string `+"`json:\"email\" version:\"2+\"`"+`
+	Legacy bool   `+"`version:\"-2\"`"+`
+	State  Status `+"`json:\"state\" version:\"3\"`"+`
+	Meta   struct {
+		ID int `+"`json:\"id\"`"+`
+	} `+"`json:\"meta\" version:\"3\"`"+`
+}
+`), 0644))
+
+	g := NewGenerator(fileName, "User", "", string(ModuleFolder), false)
+	g.HTML = true
+	assert.NoError(t, g.VersionedStructs())
+
+	content, err := os.ReadFile(filepath.Join(tempDir, "models", string(ModuleFolder), "user.md"))
+	assert.NoError(t, err)
+	checksum, body, ok := splitHeader(content)
+	assert.True(t, ok)
+	assert.NotEmpty(t, checksum)
+	assert.Equal(t, `# User
+
+Fields of `+"`User`"+` in each version, from `+"`models/user.go:User`"+`.
+
+| Field | Type | V1 | V2 | V3 | Description |
+|-------|------|:--:|:--:|:--:|-------------|
+| `+"`Name`"+` | `+"`string`"+` | ✓ | ✓ | ✓ | Name of the user, as shown to others \| not unique |
+| `+"`Age`"+` | `+"`int32`"+` | ✓ |   |   |  |
+| `+"`AgeV2`"+` | `+"`int64`"+` |   | ✓ | ✓ | Age in years |
+| `+"`Email`"+` | `+"`string`"+` |   | ✓ | ✓ |  |
+| `+"`Legacy`"+` | `+"`bool`"+` | ✓ | ✓ |   |  |
+| `+"`State`"+` | `+"`Status`"+` |   |   | ✓ |  |
+| `+"`Meta`"+` | `+"``struct{ ID int `json:\"id\"` }``"+` |   |   | ✓ |  |
+
+## V3
+
+### Added
+
+- `+"`State` `Status`"+`
+- `+"`Meta` ``struct{ ID int `json:\"id\"` }``"+`
+
+### Removed
+
+- `+"`Legacy` `bool`"+`
+
+## V2
+
+### Added
+
+- `+"`Email` `string`"+`
+
+### Changed
+
+- `+"`Age` is now `AgeV2`, type `int32` → `int64`: Age in years"+`
+
+## V1
+
+### Added
+
+- `+"`Name` `string`"+`: Name of the user, as shown to others | not unique
+- `+"`Age` `int32`"+`
+- `+"`Legacy` `bool`"+`
+`, string(body))
+
+	html, err := os.ReadFile(filepath.Join(tempDir, "models", string(ModuleFolder), "user.html"))
+	assert.NoError(t, err)
+	_, _, ok = splitHeader(html)
+	assert.True(t, ok)
+	assert.Contains(t, string(html), "<td><code>State</code></td><td><code>Status</code></td>")
+	assert.Contains(t, string(html), "<li><code>Age</code> is now <code>AgeV2</code>, type <code>int32</code> → <code>int64</code>: Age in years</li>")
+
+	// The document is regenerated with the hub, and edits to it are protected like any generated file
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "models", string(ModuleFolder), "user.md"), append(content, "edited\n"...), 0644))
+	err = NewGenerator(fileName, "User", "", string(ModuleFolder), false).VersionedStructs()
+	assert.ErrorContains(t, err, "user.md was modified by hand")
+}
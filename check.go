@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/pmezard/go-difflib/difflib"
+	"os"
+	"strings"
+)
+
+type StaleFile struct {
+	Path  string
+	Diff  string
+	Force bool // Only regenerated with --force, like the existing eras
+}
+
+// CompareFile records the file as stale when the rendered content differs
+// from what is on disk. Nothing is written.
+func (g *Generator) CompareFile(path string, content []byte) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err == nil && bytes.Equal(existing, content) {
+		return nil
+	}
+	return g.stale(path, existing, content, false)
+}
+
+// CompareEra compares an existing era the way a run without --force keeps it:
+// only its body counts, not the source struct in its header, which still
+// records the struct the era was generated from. An era whose body differs
+// is only regenerated with --force.
+func (g *Generator) CompareEra(path string, content []byte) error {
+	existing, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return g.CompareFile(path, content)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, existingBody, ok := splitHeader(existing)
+	_, body, _ := splitHeader(content)
+	if ok && bytes.Equal(existingBody, body) {
+		return nil
+	}
+	return g.stale(path, existing, content, true)
+}
+
+func (g *Generator) stale(path string, existing, content []byte, force bool) error {
+	diff, err := UnifiedDiff(path, existing, content)
+	if err != nil {
+		return err
+	}
+
+	g.StaleFiles = append(g.StaleFiles, StaleFile{Path: path, Diff: diff, Force: force})
+	return nil
+}
+
+func UnifiedDiff(path string, existing, content []byte) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(existing),
+		B:        splitLines(content),
+		FromFile: path,
+		ToFile:   path,
+		Context:  3,
+	})
+}
+
+func splitLines(content []byte) []string {
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// StaleFilesError prints the diff of every stale file and returns an error
+// when there is at least one, telling apart the eras only --force regenerates.
+func StaleFilesError(staleFiles []StaleFile) error {
+	if len(staleFiles) == 0 {
+		return nil
+	}
+
+	var paths, forcedPaths []string
+	for _, staleFile := range staleFiles {
+		fmt.Print(staleFile.Diff)
+		if staleFile.Force {
+			forcedPaths = append(forcedPaths, staleFile.Path)
+			continue
+		}
+		paths = append(paths, staleFile.Path)
+	}
+
+	var messages []string
+	if len(paths) > 0 {
+		messages = append(messages, fmt.Sprintf("%d generated files are out of date, run structera to regenerate them:\n  %s", len(paths), strings.Join(paths, "\n  ")))
+	}
+	if len(forcedPaths) > 0 {
+		messages = append(messages, fmt.Sprintf("%d existing eras are out of date, run structera with --force to regenerate them:\n  %s", len(forcedPaths), strings.Join(forcedPaths, "\n  ")))
+	}
+	return fmt.Errorf("%s", strings.Join(messages, "\n"))
+}
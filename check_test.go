@@ -0,0 +1,130 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerator_Check(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	writeBatchFixture(t, tempDir)
+	fileName := filepath.Join(tempDir, "models", "user.go")
+	versionedDir := filepath.Join(tempDir, "models", string(ModuleFolder))
+
+	// Nothing generated yet: every file is stale and nothing is written
+	g := NewGenerator(fileName, "User", "", string(ModuleFolder), false)
+	g.Check = true
+	assert.NoError(t, g.VersionedStructs())
+	assert.Len(t, g.StaleFiles, 7)
+	assert.NoDirExists(t, versionedDir)
+
+	// Freshly generated files are up to date
+	assert.NoError(t, NewGenerator(fileName, "User", "", string(ModuleFolder), false).VersionedStructs())
+	g = NewGenerator(fileName, "User", "", string(ModuleFolder), false)
+	g.Check = true
+	assert.NoError(t, g.VersionedStructs())
+	assert.Empty(t, g.StaleFiles)
+
+	// Editing the struct without regenerating makes the hub and eras stale
+	source, err := os.ReadFile(fileName)
+	assert.NoError(t, err)
+	edited := strings.Replace(string(source), "\tName  string\n", "\tName  string\n\tAge   int\n", 1)
+	assert.NoError(t, os.WriteFile(fileName, []byte(edited), 0644))
+
+	hubBefore, err := os.ReadFile(filepath.Join(versionedDir, "user.go"))
+	assert.NoError(t, err)
+
+	g = NewGenerator(fileName, "User", "", string(ModuleFolder), false)
+	g.Check = true
+	assert.NoError(t, g.VersionedStructs())
+
+	var stalePaths []string
+	for _, staleFile := range g.StaleFiles {
+		stalePaths = append(stalePaths, staleFile.Path)
+		if filepath.Ext(staleFile.Path) == ".md" {
+			assert.Contains(t, staleFile.Diff, "\n+| `Age` | `int` |")
+			continue
+		}
+		if filepath.Base(staleFile.Path) == "migrate.go" {
+			assert.Regexp(t, `\n\+\s+Age:\s+era\.Age,\n`, staleFile.Diff)
+			continue
+		}
+		assert.Regexp(t, `\n\+\s+Age\s+\*?int\n`, staleFile.Diff)
+	}
+	assert.ElementsMatch(t, []string{
+		filepath.Join(versionedDir, "user.go"),
+		filepath.Join(versionedDir, "user.md"),
+		filepath.Join(versionedDir, "user", "v1.go"),
+		filepath.Join(versionedDir, "user", "v2.go"),
+		filepath.Join(versionedDir, "user", "v3.go"),
+		filepath.Join(versionedDir, "user", "migrate.go"),
+	}, stalePaths)
+
+	hubAfter, err := os.ReadFile(filepath.Join(versionedDir, "user.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, string(hubBefore), string(hubAfter))
+
+	// The existing eras are only regenerated with --force, and the error says so
+	for _, staleFile := range g.StaleFiles {
+		assert.Equal(t, filepath.Base(filepath.Dir(staleFile.Path)) == "user" && filepath.Base(staleFile.Path) != "migrate.go", staleFile.Force, staleFile.Path)
+	}
+	err = StaleFilesError(g.StaleFiles)
+	assert.ErrorContains(t, err, "3 generated files are out of date, run structera to regenerate them")
+	assert.ErrorContains(t, err, "3 existing eras are out of date, run structera with --force to regenerate them:\n  "+filepath.Join(versionedDir, "user", "v1.go"))
+}
+
+func TestGenerator_Check_SkippedEras(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	writeBatchFixture(t, tempDir)
+	fileName := filepath.Join(tempDir, "models", "user.go")
+	assert.NoError(t, NewGenerator(fileName, "User", "", string(ModuleFolder), false).VersionedStructs())
+
+	// A new version changes the source struct, but not the eras a normal run skips
+	source, err := os.ReadFile(fileName)
+	assert.NoError(t, err)
+	edited := strings.Replace(string(source), "\tName  string\n", "\tName  string\n\tPhone string `version:\"4\"`\n", 1)
+	assert.NoError(t, os.WriteFile(fileName, []byte(edited), 0644))
+	assert.NoError(t, NewGenerator(fileName, "User", "", string(ModuleFolder), false).VersionedStructs())
+
+	g := NewGenerator(fileName, "User", "", string(ModuleFolder), false)
+	g.Check = true
+	assert.NoError(t, g.VersionedStructs())
+	assert.Empty(t, g.StaleFiles)
+
+	// Checking as a forced run would regenerate them compares their headers too
+	g = NewGenerator(fileName, "User", "", string(ModuleFolder), true)
+	g.Check = true
+	assert.NoError(t, g.VersionedStructs())
+	assert.Len(t, g.StaleFiles, 3)
+	for _, staleFile := range g.StaleFiles {
+		assert.False(t, staleFile.Force)
+		assert.Contains(t, staleFile.Diff, "\n-// Source: models/user.go:User sha256:")
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	diff, err := UnifiedDiff("file.go", []byte("a\nb\nc\n"), []byte("a\nB\nc\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "--- file.go\n+++ file.go\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n", diff)
+}
+
+func TestStaleFilesError(t *testing.T) {
+	assert.NoError(t, StaleFilesError(nil))
+	assert.EqualError(t, StaleFilesError([]StaleFile{{Path: "file.go"}}), "1 generated files are out of date, run structera to regenerate them:\n  file.go")
+	assert.EqualError(t, StaleFilesError([]StaleFile{{Path: "v1.go", Force: true}}), "1 existing eras are out of date, run structera with --force to regenerate them:\n  v1.go")
+}
@@ -0,0 +1,681 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var versionSuffix = regexp.MustCompile(`V[0-9]+$`)
+
+type ChangeKind string
+
+const (
+	FieldAdded   ChangeKind = "added"
+	FieldRemoved ChangeKind = "removed"
+	TypeChanged  ChangeKind = "type_changed"
+	TagChanged   ChangeKind = "tag_changed"
+)
+
+type Compatibility string
+
+const (
+	WireCompatible Compatibility = "wire-compatible"
+	Breaking       Compatibility = "breaking"
+)
+
+// Impact tells whether the readers of one era still decode the JSON written by the other.
+type Impact struct {
+	Compatibility Compatibility `json:"compatibility"`
+	Reason        string        `json:"reason"`
+}
+
+type FieldChange struct {
+	Field    string     `json:"field"`
+	ToField  string     `json:"to_field,omitempty"`
+	Kind     ChangeKind `json:"kind"`
+	From     string     `json:"from,omitempty"`
+	To       string     `json:"to,omitempty"`
+	Backward Impact     `json:"backward"`
+	Forward  Impact     `json:"forward"`
+}
+
+// CompatReport classifies the changes between two eras of a struct. Backward
+// is about readers of the new era decoding payloads of the old one, forward
+// about readers of the old era decoding payloads of the new one.
+type CompatReport struct {
+	Struct   string        `json:"struct"`
+	Source   string        `json:"source"`
+	From     int           `json:"from"`
+	To       int           `json:"to"`
+	Backward Compatibility `json:"backward"`
+	Forward  Compatibility `json:"forward"`
+	Changes  []FieldChange `json:"changes"`
+}
+
+func (r *CompatReport) Breaking() bool {
+	return r.Backward == Breaking || r.Forward == Breaking
+}
+
+func (r *CompatReport) JSON() ([]byte, error) {
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(content, '\n'), nil
+}
+
+func (r *CompatReport) String() string {
+	var report strings.Builder
+	fmt.Fprintf(&report, "%s V%d -> V%d (%s)\n", r.Struct, r.From, r.To, r.Source)
+	if len(r.Changes) == 0 {
+		report.WriteString("\n  no changes\n")
+	}
+	for _, change := range r.Changes {
+		if change.ToField != "" {
+			change.Field += " -> " + change.ToField
+		}
+		switch change.Kind {
+		case FieldAdded:
+			fmt.Fprintf(&report, "\n  added         %s %s\n", change.Field, change.To)
+		case FieldRemoved:
+			fmt.Fprintf(&report, "\n  removed       %s %s\n", change.Field, change.From)
+		case TypeChanged:
+			fmt.Fprintf(&report, "\n  type changed  %s: %s -> %s\n", change.Field, change.From, change.To)
+		case TagChanged:
+			fmt.Fprintf(&report, "\n  tag changed   %s: `%s` -> `%s`\n", change.Field, change.From, change.To)
+		}
+		fmt.Fprintf(&report, "    backward  %-15s  %s\n", change.Backward.Compatibility, change.Backward.Reason)
+		fmt.Fprintf(&report, "    forward   %-15s  %s\n", change.Forward.Compatibility, change.Forward.Reason)
+	}
+	fmt.Fprintf(&report, "\nbackward (V%d reads V%d): %s\n", r.To, r.From, r.Backward)
+	fmt.Fprintf(&report, "forward  (V%d reads V%d): %s\n", r.From, r.To, r.Forward)
+	return report.String()
+}
+
+// Compat compares two eras of the struct with the fields IdentifyVersions
+// assigns to each version. Zero versions default to the last two eras.
+func (g *Generator) Compat(from, to int) (*CompatReport, error) {
+	fileSet, node, structType, err := g.ParseStruct()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.Format.ValidateVersionTags(fileSet, structType); err != nil {
+		return nil, err
+	}
+
+	if err := g.fingerprint(fileSet, structType); err != nil {
+		return nil, err
+	}
+
+	// Types declared next to the struct are shown with the name of their package
+	g.Format.SourceAlias = node.Name.Name
+	g.Format.IdentifyVersions(structType)
+	if len(g.Format.Versions) == 0 {
+		return nil, fmt.Errorf("no version tags found in struct")
+	}
+
+	fields, _, err := g.ProcessFieldInfo(fileSet, structType)
+	if err != nil {
+		return nil, err
+	}
+	g.ProcessedFields = fields
+	g.PrepareVersionedFields()
+
+	versions := g.Format.SortedVersions
+	if to == 0 {
+		to = versions[len(versions)-1]
+	}
+	if from == 0 {
+		from = to - 1
+	}
+	for _, version := range []int{from, to} {
+		if _, ok := g.VersionedFields[version]; !ok {
+			return nil, fmt.Errorf("struct '%s' has no era V%d, its eras go from V%d to V%d", g.StructName.Original, version, versions[0], versions[len(versions)-1])
+		}
+	}
+	// Added and removed only make sense from the older era to the newer one
+	if from >= to {
+		return nil, fmt.Errorf("era V%d to compare from must be older than era V%d to compare to", from, to)
+	}
+
+	checker := newJSONChecker(node)
+	report := &CompatReport{
+		Struct:   g.StructName.Original,
+		Source:   g.SourceName,
+		From:     from,
+		To:       to,
+		Backward: WireCompatible,
+		Forward:  WireCompatible,
+		Changes:  []FieldChange{},
+	}
+
+	fromFields := checker.promotedFields(g.VersionedFields[from], g.Format, nil)
+	toFields := checker.promotedFields(g.VersionedFields[to], g.Format, nil)
+	removed, added, changed := diffEras(fromFields, toFields)
+	for _, pair := range changed {
+		if pair.from.Type != pair.to.Type {
+			report.add(checker.typeChange(pair.from, pair.to))
+		}
+		if pair.from.Tag != pair.to.Tag {
+			report.add(tagChange(pair.from, pair.to, from, to))
+		}
+	}
+	for _, field := range removed {
+		report.add(removedField(field, from, to))
+	}
+	for _, field := range added {
+		report.add(addedField(field, from, to))
+	}
+
+	return report, nil
+}
+
+type fieldPair struct {
+	from HubFieldInfo
+	to   HubFieldInfo
+}
+
+// diffEras returns the fields removed and added between two eras. A field
+// changing between eras is declared again with other versions, so it's
+// paired with its new declaration by JSON key or by base name.
+func diffEras(fromFields, toFields []HubFieldInfo) ([]HubFieldInfo, []HubFieldInfo, []fieldPair) {
+	var removed, added []HubFieldInfo
+	var changed []fieldPair
+	for _, fromField := range fromFields {
+		toField, ok := findField(toFields, fromField.Name)
+		if !ok {
+			removed = append(removed, fromField)
+		} else if toField.Type != fromField.Type || toField.Tag != fromField.Tag {
+			// Fields promoted from different embedded structs can share a name
+			changed = append(changed, fieldPair{from: fromField, to: toField})
+		}
+	}
+	for _, toField := range toFields {
+		if _, ok := findField(fromFields, toField.Name); !ok {
+			added = append(added, toField)
+		}
+	}
+
+	var unpaired []HubFieldInfo
+	for _, fromField := range removed {
+		index := pairField(fromField, added)
+		if index < 0 {
+			unpaired = append(unpaired, fromField)
+			continue
+		}
+
+		changed = append(changed, fieldPair{from: fromField, to: added[index]})
+		added = append(added[:index], added[index+1:]...)
+	}
+
+	return unpaired, added, changed
+}
+
+// pairField returns the index of the added field that replaces the removed
+// one: the one encoded with the same JSON key, or else the one with the same
+// name once a version suffix is dropped (Email and EmailV4).
+func pairField(removed HubFieldInfo, added []HubFieldInfo) int {
+	if key, _ := jsonKey(removed); key != "" {
+		for i, field := range added {
+			if addedKey, _ := jsonKey(field); strings.EqualFold(key, addedKey) {
+				return i
+			}
+		}
+	}
+	for i, field := range added {
+		if baseName(field.Name) == baseName(removed.Name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func baseName(name string) string {
+	return versionSuffix.ReplaceAllString(name, "")
+}
+
+// FindStruct returns the file declaring the versioned struct, among the models
+// of the config file when there is one, or else the packages of the directory tree.
+func FindStruct(structName, configPath string) (string, error) {
+	var targets []BatchTarget
+	resolver := Resolver{ConfigPath: configPath}
+	if configPath != "" || resolver.FindConfigPath(".") == nil {
+		config, err := LoadConfig(resolver.ConfigPath)
+		if err != nil {
+			return "", err
+		}
+		if targets, err = config.Targets(false); err != nil {
+			return "", err
+		}
+	} else {
+		batch := Batch{Patterns: []string{"./..."}}
+		var err error
+		if targets, err = batch.FindTargets(); err != nil {
+			return "", err
+		}
+	}
+
+	var files []string
+	for _, target := range targets {
+		if target.StructName == structName {
+			files = append(files, target.Filename)
+		}
+	}
+	switch len(files) {
+	case 0:
+		return "", fmt.Errorf("versioned struct '%s' not found, pass its file with -f", structName)
+	case 1:
+		return files[0], nil
+	}
+	return "", fmt.Errorf("versioned struct '%s' is declared in %s, pick one with -f", structName, strings.Join(files, ", "))
+}
+
+func (r *CompatReport) add(change FieldChange) {
+	if change.Backward.Compatibility == Breaking {
+		r.Backward = Breaking
+	}
+	if change.Forward.Compatibility == Breaking {
+		r.Forward = Breaking
+	}
+	r.Changes = append(r.Changes, change)
+}
+
+func findField(fields []HubFieldInfo, name string) (HubFieldInfo, bool) {
+	for _, field := range fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return HubFieldInfo{}, false
+}
+
+// jsonKey returns the key encoding/json uses for the field, empty when the
+// field isn't encoded, and whether the value is quoted with the string option.
+func jsonKey(field HubFieldInfo) (string, bool) {
+	if !ast.IsExported(field.Name) {
+		return "", false
+	}
+
+	tag := reflect.StructTag(field.Tag).Get("json")
+	if tag == "-" {
+		return "", false
+	}
+
+	parts := strings.Split(tag, ",")
+	key := parts[0]
+	if key == "" {
+		key = field.Name
+	}
+
+	quoted := false
+	for _, option := range parts[1:] {
+		if option == "string" {
+			quoted = true
+		}
+	}
+	return key, quoted
+}
+
+// renamed returns the name of the new declaration when it differs.
+func renamed(fromField, toField HubFieldInfo) string {
+	if fromField.Name == toField.Name {
+		return ""
+	}
+	return toField.Name
+}
+
+func compatible(reason string, args ...any) Impact {
+	return Impact{Compatibility: WireCompatible, Reason: fmt.Sprintf(reason, args...)}
+}
+
+func breaking(reason string, args ...any) Impact {
+	return Impact{Compatibility: Breaking, Reason: fmt.Sprintf(reason, args...)}
+}
+
+// Unknown keys are ignored and missing ones leave the field at its zero value,
+// so adding or removing a field never breaks decoding.
+func addedField(field HubFieldInfo, from, to int) FieldChange {
+	change := FieldChange{Field: field.Name, Kind: FieldAdded, To: field.Type}
+	key, _ := jsonKey(field)
+	if key == "" {
+		change.Backward = compatible("%s isn't encoded in JSON", field.Name)
+		change.Forward = change.Backward
+		return change
+	}
+
+	change.Backward = compatible("V%d payloads don't carry %q, V%d leaves %s at its zero value", from, key, to, field.Name)
+	change.Forward = compatible("V%d ignores the unknown %q key", from, key)
+	return change
+}
+
+func removedField(field HubFieldInfo, from, to int) FieldChange {
+	change := FieldChange{Field: field.Name, Kind: FieldRemoved, From: field.Type}
+	key, _ := jsonKey(field)
+	if key == "" {
+		change.Backward = compatible("%s isn't encoded in JSON", field.Name)
+		change.Forward = change.Backward
+		return change
+	}
+
+	change.Backward = compatible("V%d ignores the unknown %q key", to, key)
+	change.Forward = compatible("V%d payloads don't carry %q, V%d leaves %s at its zero value", to, key, from, field.Name)
+	return change
+}
+
+// tagChange only cares about the json tag: the key the field is encoded with
+// and the string option. Other tags don't change the payload.
+func tagChange(fromField, toField HubFieldInfo, from, to int) FieldChange {
+	change := FieldChange{Field: fromField.Name, ToField: renamed(fromField, toField), Kind: TagChanged, From: fromField.Tag, To: toField.Tag}
+	fromKey, fromQuoted := jsonKey(fromField)
+	toKey, toQuoted := jsonKey(toField)
+
+	switch {
+	case fromKey == "" && toKey == "":
+		change.Backward = compatible("%s isn't encoded in JSON", fromField.Name)
+		change.Forward = change.Backward
+	case toKey == "":
+		change.Backward = compatible("V%d ignores the %q key", to, fromKey)
+		change.Forward = compatible("V%d payloads don't carry %q, V%d leaves %s at its zero value", to, fromKey, from, fromField.Name)
+	case fromKey == "":
+		change.Backward = compatible("V%d payloads don't carry %q, V%d leaves %s at its zero value", from, toKey, to, fromField.Name)
+		change.Forward = compatible("V%d ignores the %q key", from, toKey)
+	case !strings.EqualFold(fromKey, toKey):
+		// encoding/json matches keys case-insensitively, only real renames lose the value
+		change.Backward = breaking("V%d reads %s from %q, V%d payloads carry it in %q", to, fromField.Name, toKey, from, fromKey)
+		change.Forward = breaking("V%d reads %s from %q, V%d payloads carry it in %q", from, fromField.Name, fromKey, to, toKey)
+	case fromQuoted != toQuoted:
+		change.Backward = breaking("the string option changes whether %q is quoted in JSON", toKey)
+		change.Forward = change.Backward
+	default:
+		change.Backward = compatible("the JSON encoding of %q doesn't change", toKey)
+		change.Forward = change.Backward
+	}
+	return change
+}
+
+// jsonChecker tells whether the JSON encoding of a Go type decodes into
+// another. Types declared next to the struct are looked through, unless they
+// have their own JSON or text encoding.
+type jsonChecker struct {
+	alias      string
+	decls      map[string]ast.Expr
+	marshalers map[string]bool
+}
+
+type jsonKind string
+
+const (
+	jsonAny    jsonKind = "any"
+	jsonString jsonKind = "string"
+	jsonNumber jsonKind = "number"
+	jsonBool   jsonKind = "bool"
+	jsonArray  jsonKind = "array"
+	jsonObject jsonKind = "object"
+)
+
+type numberType struct {
+	bits   int
+	signed bool
+	float  bool
+}
+
+var numberTypes = map[string]numberType{
+	"int": {64, true, false}, "int8": {8, true, false}, "int16": {16, true, false}, "int32": {32, true, false}, "int64": {64, true, false},
+	"uint": {64, false, false}, "uint8": {8, false, false}, "uint16": {16, false, false}, "uint32": {32, false, false}, "uint64": {64, false, false},
+	"byte": {8, false, false}, "rune": {32, true, false}, "uintptr": {64, false, false},
+	"float32": {32, true, true}, "float64": {64, true, true},
+}
+
+func newJSONChecker(node *ast.File) *jsonChecker {
+	checker := &jsonChecker{
+		alias:      node.Name.Name,
+		decls:      make(map[string]ast.Expr),
+		marshalers: make(map[string]bool),
+	}
+
+	for _, decl := range node.Decls {
+		switch d := decl.(type) {
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				if typeSpec, ok := spec.(*ast.TypeSpec); ok && typeSpec.TypeParams == nil {
+					checker.decls[typeSpec.Name.Name] = typeSpec.Type
+				}
+			}
+		case *ast.FuncDecl:
+			if d.Recv == nil || len(d.Recv.List) == 0 {
+				continue
+			}
+			switch d.Name.Name {
+			case "MarshalJSON", "UnmarshalJSON", "MarshalText", "UnmarshalText":
+				receiver := d.Recv.List[0].Type
+				if star, ok := receiver.(*ast.StarExpr); ok {
+					receiver = star.X
+				}
+				if receiver, ok := receiver.(*ast.Ident); ok {
+					checker.marshalers[receiver.Name] = true
+				}
+			}
+		}
+	}
+	return checker
+}
+
+// promotedFields replaces the embedded structs with the fields they promote,
+// which encoding/json writes as keys of the struct embedding them. Embedded
+// types it can't look through stay as they are.
+func (c *jsonChecker) promotedFields(fields []HubFieldInfo, format *Format, seen map[string]bool) []HubFieldInfo {
+	var promoted []HubFieldInfo
+	for _, field := range fields {
+		structType := c.embeddedStruct(field)
+		if structType == nil || seen[field.Type] {
+			promoted = append(promoted, field)
+			continue
+		}
+
+		var inner []HubFieldInfo
+		for _, innerField := range structType.Fields.List {
+			tag := ""
+			if innerField.Tag != nil {
+				tag, _ = strconv.Unquote(innerField.Tag.Value)
+			}
+			for _, name := range format.FieldNames(innerField) {
+				inner = append(inner, HubFieldInfo{
+					Name:     name,
+					Type:     format.FieldType(innerField.Type, false),
+					Tag:      tag,
+					Embedded: len(innerField.Names) == 0,
+				})
+			}
+		}
+
+		// An embedded struct embedding itself through a pointer is expanded once
+		innerSeen := map[string]bool{field.Type: true}
+		for key := range seen {
+			innerSeen[key] = true
+		}
+		promoted = append(promoted, c.promotedFields(inner, format, innerSeen)...)
+	}
+	return promoted
+}
+
+// embeddedStruct returns the struct type of an embedded field whose fields
+// are promoted, or nil when the field is encoded under a key of its own.
+func (c *jsonChecker) embeddedStruct(field HubFieldInfo) *ast.StructType {
+	if !field.Embedded {
+		return nil
+	}
+	if name := strings.Split(reflect.StructTag(field.Tag).Get("json"), ",")[0]; name != "" {
+		return nil
+	}
+
+	expr, err := parser.ParseExpr(field.Type)
+	if err != nil {
+		return nil
+	}
+	structType, _ := c.resolve(expr).(*ast.StructType)
+	return structType
+}
+
+func (c *jsonChecker) typeChange(fromField, toField HubFieldInfo) FieldChange {
+	change := FieldChange{Field: fromField.Name, ToField: renamed(fromField, toField), Kind: TypeChanged, From: fromField.Type, To: toField.Type}
+	fromKey, _ := jsonKey(fromField)
+	toKey, _ := jsonKey(toField)
+	if fromKey == "" || toKey == "" {
+		change.Backward = compatible("%s isn't encoded in JSON by both eras", fromField.Name)
+		change.Forward = change.Backward
+		return change
+	}
+
+	change.Backward = c.impact(fromField.Type, toField.Type)
+	change.Forward = c.impact(toField.Type, fromField.Type)
+	return change
+}
+
+// impact tells whether the readers of the reader type decode the JSON
+// written from the writer type.
+func (c *jsonChecker) impact(writer, reader string) Impact {
+	writerExpr, writerErr := parser.ParseExpr(writer)
+	readerExpr, readerErr := parser.ParseExpr(reader)
+	if writerErr != nil || readerErr != nil {
+		return breaking("can't tell whether %s values decode into %s", writer, reader)
+	}
+
+	decodes, known := c.decodes(writerExpr, readerExpr)
+	switch {
+	case !known:
+		return breaking("can't tell whether %s values decode into %s", writer, reader)
+	case !decodes:
+		return breaking("%s values don't always decode into %s", writer, reader)
+	}
+	return compatible("%s values decode into %s", writer, reader)
+}
+
+// decodes reports whether every JSON value written from the writer type
+// decodes into the reader type, and whether that could be told at all.
+func (c *jsonChecker) decodes(writer, reader ast.Expr) (bool, bool) {
+	writer, reader = c.resolve(writer), c.resolve(reader)
+	if types.ExprString(writer) == types.ExprString(reader) {
+		return true, true
+	}
+
+	writerKind, readerKind := c.kind(writer), c.kind(reader)
+	switch {
+	case readerKind == jsonAny:
+		return true, true
+	case writerKind == "" || readerKind == "":
+		return false, false
+	case writerKind != readerKind:
+		return false, true
+	}
+
+	switch readerKind {
+	case jsonNumber:
+		return numberFits(numberTypes[types.ExprString(writer)], numberTypes[types.ExprString(reader)]), true
+	case jsonArray:
+		// Extra elements of a longer array are dropped, so the length doesn't matter
+		return c.decodes(writer.(*ast.ArrayType).Elt, reader.(*ast.ArrayType).Elt)
+	case jsonObject:
+		writerMap, writerOk := writer.(*ast.MapType)
+		readerMap, readerOk := reader.(*ast.MapType)
+		if !writerOk || !readerOk {
+			return false, false
+		}
+		return c.decodes(writerMap.Value, readerMap.Value)
+	}
+	return true, true
+}
+
+// resolve drops pointers, which encode like their element, and looks through
+// the types declared next to the struct.
+func (c *jsonChecker) resolve(expr ast.Expr) ast.Expr {
+	seen := make(map[string]bool)
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+			continue
+		case *ast.ParenExpr:
+			expr = t.X
+			continue
+		}
+
+		name := ""
+		switch t := expr.(type) {
+		case *ast.Ident:
+			name = t.Name
+		case *ast.SelectorExpr:
+			if x, ok := t.X.(*ast.Ident); ok && x.Name == c.alias {
+				name = t.Sel.Name
+			}
+		}
+
+		decl, ok := c.decls[name]
+		if !ok || c.marshalers[name] || seen[name] {
+			return expr
+		}
+		seen[name] = true
+		expr = decl
+	}
+}
+
+func (c *jsonChecker) kind(expr ast.Expr) jsonKind {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		switch t.Name {
+		case "any":
+			return jsonAny
+		case "string":
+			return jsonString
+		case "bool":
+			return jsonBool
+		}
+		if _, ok := numberTypes[t.Name]; ok {
+			return jsonNumber
+		}
+	case *ast.InterfaceType:
+		if len(t.Methods.List) == 0 {
+			return jsonAny
+		}
+	case *ast.SelectorExpr:
+		if types.ExprString(t) == "json.RawMessage" {
+			return jsonAny
+		}
+	case *ast.ArrayType:
+		// Byte slices are encoded as base64 strings
+		if elt, ok := c.resolve(t.Elt).(*ast.Ident); ok && t.Len == nil && (elt.Name == "byte" || elt.Name == "uint8") {
+			return jsonString
+		}
+		return jsonArray
+	case *ast.MapType, *ast.StructType:
+		return jsonObject
+	}
+	return ""
+}
+
+// numberFits reports whether every number of the writer type fits the reader
+// type. Fractions don't fit integers, nor negatives unsigned integers.
+func numberFits(writer, reader numberType) bool {
+	switch {
+	case reader.float:
+		return !writer.float || reader.bits >= writer.bits
+	case writer.float:
+		return false
+	case writer.signed && !reader.signed:
+		return false
+	case !writer.signed && reader.signed:
+		return reader.bits > writer.bits
+	}
+	return reader.bits >= writer.bits
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerator_Compat(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	fileName := filepath.Join(tempDir, "user.go")
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte("module example.com/compat\n"), 0644))
+	assert.NoError(t, os.WriteFile(fileName, []byte(`package models
+
+type Status string
+
+type Score struct{ Value float64 }
+
+func (s Score) MarshalJSON() ([]byte, error) { return nil, nil }
+
+type User struct {
+	Name    string            `+"`json:\"name\"`"+`
+	Age     int32             `+"`json:\"age\" version:\"1\"`"+`
+	AgeV2   int64             `+"`json:\"age\" version:\"2\"`"+`
+	Rating  float64           `+"`json:\"rating\" version:\"1\"`"+`
+	Ratings []int             `+"`json:\"Rating\" version:\"2\"`"+`
+	Email   string            `+"`json:\"email\" version:\"1\"`"+`
+	EmailV2 string            `+"`json:\"email_address\" version:\"2\"`"+`
+	Nick    string            `+"`json:\"nick\" version:\"1\"`"+`
+	NickV2  string            `+"`json:\"Nick,omitempty\" version:\"2\"`"+`
+	State   Status            `+"`json:\"state\" version:\"1\"`"+`
+	StateV2 *string           `+"`json:\"state\" version:\"2\"`"+`
+	Extra   map[string]int    `+"`json:\"extra\" version:\"1\"`"+`
+	ExtraV2 map[string]any    `+"`json:\"extra\" version:\"2\"`"+`
+	Grade   Score             `+"`json:\"grade\" version:\"1\"`"+`
+	GradeV2 float64           `+"`json:\"grade\" version:\"2\"`"+`
+	Legacy  bool              `+"`json:\"legacy\" version:\"1\"`"+`
+	Phone   string            `+"`json:\"phone\" version:\"2\"`"+`
+	secret  string            `+"`version:\"2\"`"+`
+}
+`), 0644))
+
+	report, err := NewGenerator(fileName, "User", "", string(ModuleFolder), false).Compat(1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "User", report.Struct)
+	assert.Equal(t, "user.go:User", report.Source)
+	assert.Equal(t, Breaking, report.Backward)
+	assert.Equal(t, Breaking, report.Forward)
+	assert.True(t, report.Breaking())
+
+	type summary struct {
+		field, toField    string
+		kind              ChangeKind
+		backward, forward Compatibility
+	}
+	var changes []summary
+	for _, change := range report.Changes {
+		changes = append(changes, summary{change.Field, change.ToField, change.Kind, change.Backward.Compatibility, change.Forward.Compatibility})
+	}
+	assert.Equal(t, []summary{
+		{"Age", "AgeV2", TypeChanged, WireCompatible, Breaking},
+		{"Rating", "Ratings", TypeChanged, Breaking, Breaking},
+		{"Rating", "Ratings", TagChanged, WireCompatible, WireCompatible},
+		{"Email", "EmailV2", TagChanged, Breaking, Breaking},
+		{"Nick", "NickV2", TagChanged, WireCompatible, WireCompatible},
+		{"State", "StateV2", TypeChanged, WireCompatible, WireCompatible},
+		{"Extra", "ExtraV2", TypeChanged, WireCompatible, Breaking},
+		{"Grade", "GradeV2", TypeChanged, Breaking, Breaking},
+		{"Legacy", "", FieldRemoved, WireCompatible, WireCompatible},
+		{"Phone", "", FieldAdded, WireCompatible, WireCompatible},
+		{"secret", "", FieldAdded, WireCompatible, WireCompatible},
+	}, changes)
+
+	assert.Equal(t, "models.Status", report.Changes[5].From)
+	assert.Equal(t, "int32 values decode into int64", report.Changes[0].Backward.Reason)
+	assert.Equal(t, "int64 values don't always decode into int32", report.Changes[0].Forward.Reason)
+	assert.Equal(t, "can't tell whether models.Score values decode into float64", report.Changes[7].Backward.Reason)
+	assert.Equal(t, `V2 reads Email from "email_address", V1 payloads carry it in "email"`, report.Changes[3].Backward.Reason)
+
+	// Eras are compared from the older one to the newer one
+	_, err = NewGenerator(fileName, "User", "", string(ModuleFolder), false).Compat(2, 1)
+	assert.EqualError(t, err, "era V2 to compare from must be older than era V1 to compare to")
+	_, err = NewGenerator(fileName, "User", "", string(ModuleFolder), false).Compat(2, 2)
+	assert.EqualError(t, err, "era V2 to compare from must be older than era V2 to compare to")
+
+	// The last two eras are compared by default
+	report, err = NewGenerator(fileName, "User", "", string(ModuleFolder), false).Compat(0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.From)
+	assert.Equal(t, 2, report.To)
+
+	_, err = NewGenerator(fileName, "User", "", string(ModuleFolder), false).Compat(1, 3)
+	assert.EqualError(t, err, "struct 'User' has no era V3, its eras go from V1 to V2")
+}
+
+func TestGenerator_Compat_Embedded(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	fileName := filepath.Join(tempDir, "user.go")
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte("module example.com/compat\n"), 0644))
+	assert.NoError(t, os.WriteFile(fileName, []byte(`package models
+
+type Audit struct {
+	CreatedBy string `+"`json:\"created_by\"`"+`
+}
+
+type Timestamps struct {
+	UpdatedAt int64 `+"`json:\"updated_at\"`"+`
+}
+
+type Tracked struct {
+	CreatedBy int `+"`json:\"created_by\"`"+`
+	Timestamps
+}
+
+type User struct {
+	Name   string `+"`json:\"name\"`"+`
+	Audit  `+"`version:\"1\"`"+`
+	Author string `+"`json:\"created_by\" version:\"2\"`"+`
+	*Timestamps `+"`version:\"2\"`"+`
+	Tracked `+"`version:\"3\"`"+`
+}
+`), 0644))
+
+	// The promoted keys are paired, not the embedded field names
+	report, err := NewGenerator(fileName, "User", "", string(ModuleFolder), false).Compat(1, 2)
+	assert.NoError(t, err)
+	assert.Len(t, report.Changes, 1)
+	assert.Equal(t, FieldChange{
+		Field:    "UpdatedAt",
+		Kind:     FieldAdded,
+		To:       "int64",
+		Backward: compatible(`V1 payloads don't carry "updated_at", V2 leaves UpdatedAt at its zero value`),
+		Forward:  compatible(`V1 ignores the unknown "updated_at" key`),
+	}, report.Changes[0])
+
+	// Fields promoted through several embedded structs
+	report, err = NewGenerator(fileName, "User", "", string(ModuleFolder), false).Compat(2, 3)
+	assert.NoError(t, err)
+	assert.Len(t, report.Changes, 1)
+	assert.Equal(t, "Author", report.Changes[0].Field)
+	assert.Equal(t, "CreatedBy", report.Changes[0].ToField)
+	assert.Equal(t, TypeChanged, report.Changes[0].Kind)
+	assert.Equal(t, Breaking, report.Backward)
+}
+
+func TestNumberFits(t *testing.T) {
+	testCases := []struct {
+		writer, reader string
+		fits           bool
+	}{
+		{"int8", "int16", true},
+		{"int64", "int32", false},
+		{"uint8", "int16", true},
+		{"uint32", "int32", false},
+		{"int", "uint64", false},
+		{"int64", "float64", true},
+		{"float32", "float64", true},
+		{"float64", "float32", false},
+		{"float32", "int64", false},
+		{"byte", "uint8", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.writer+" to "+tc.reader, func(t *testing.T) {
+			assert.Equal(t, tc.fits, numberFits(numberTypes[tc.writer], numberTypes[tc.reader]))
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+)
+
+const ConfigFileName = "structera.yaml"
+
+type ModelConfig struct {
+	Source  string `yaml:"source"`
+	Struct  string `yaml:"struct"`
+	Output  string `yaml:"output"`
+	Package string `yaml:"package"`
+	Force   *bool  `yaml:"force"`
+	HTML    *bool  `yaml:"html"`
+	Release string `yaml:"release"`
+}
+
+type Config struct {
+	Path    string        `yaml:"-"`
+	Output  string        `yaml:"output"`
+	Package string        `yaml:"package"`
+	Force   bool          `yaml:"force"`
+	HTML    bool          `yaml:"html"`
+	Models  []ModelConfig `yaml:"models"`
+}
+
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{Path: path}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	if len(config.Models) == 0 {
+		return nil, fmt.Errorf("no models declared in %s", path)
+	}
+
+	return config, nil
+}
+
+// Targets resolves every declared model into the structs to generate. Paths
+// are relative to the directory of the config file.
+func (c *Config) Targets(force bool) ([]BatchTarget, error) {
+	var targets []BatchTarget
+	for i, model := range c.Models {
+		if model.Source == "" {
+			return nil, fmt.Errorf("model %d in %s has no source", i+1, c.Path)
+		}
+
+		batch := Batch{
+			Patterns:  []string{c.path(model.Source)},
+			OutputDir: c.path(c.option(model.Output, c.Output)),
+			Package:   c.option(model.Package, c.Package, string(ModuleFolder)),
+			Replace:   force || c.Force,
+			HTML:      c.HTML,
+			Release:   model.Release,
+		}
+		if model.Force != nil {
+			batch.Replace = force || *model.Force
+		}
+		if model.HTML != nil {
+			batch.HTML = *model.HTML
+		}
+
+		found, err := batch.FindTargets()
+		if err != nil {
+			return nil, err
+		}
+
+		matched := 0
+		for _, target := range found {
+			if model.Struct == "" || model.Struct == target.StructName {
+				targets = append(targets, target)
+				matched++
+			}
+		}
+		if matched == 0 {
+			if model.Struct != "" {
+				return nil, fmt.Errorf("versioned struct '%s' not found in '%s'", model.Struct, model.Source)
+			}
+			return nil, fmt.Errorf("no versioned structs found in '%s'", model.Source)
+		}
+	}
+
+	return targets, nil
+}
+
+func (c *Config) path(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(c.Path), path)
+}
+
+func (c *Config) option(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	tests := []struct {
+		name    string
+		content string
+		want    *Config
+		wantErr bool
+	}{
+		{
+			name:    "Valid config",
+			content: "output: ./generated\npackage: api\nmodels:\n  - source: ./models/user.go\n    struct: User\n    force: true\n  - source: ./models/...\n",
+			want: &Config{
+				Output:  "./generated",
+				Package: "api",
+				Models: []ModelConfig{
+					{Source: "./models/user.go", Struct: "User", Force: func() *bool { b := true; return &b }()},
+					{Source: "./models/..."},
+				},
+			},
+		},
+		{
+			name:    "No models",
+			content: "output: ./generated\n",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid yaml",
+			content: "models: [",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tempDir, ConfigFileName)
+			assert.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+
+			config, err := LoadConfig(path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			tt.want.Path = path
+			assert.Equal(t, tt.want, config)
+		})
+	}
+}
+
+func TestConfig_Targets(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	writeBatchFixture(t, tempDir)
+	configPath := filepath.Join(tempDir, ConfigFileName)
+	noForce := false
+
+	tests := []struct {
+		name    string
+		config  Config
+		want    []BatchTarget
+		wantErr bool
+	}{
+		{
+			name: "Defaults and per-model options",
+			config: Config{
+				Path:   configPath,
+				Output: "generated",
+				Force:  true,
+				Models: []ModelConfig{
+					{Source: "models/user.go", Struct: "User", Package: "api"},
+					{Source: "models/...", Struct: "Admin", Output: "admin", Force: &noForce, Release: "1-2"},
+				},
+			},
+			want: []BatchTarget{
+				{
+					Filename:   filepath.Join(tempDir, "models", "user.go"),
+					StructName: "User",
+					OutputDir:  filepath.Join(tempDir, "generated"),
+					Package:    "api",
+					Replace:    true,
+				},
+				{
+					Filename:   filepath.Join(tempDir, "models", "admin", "admin.go"),
+					StructName: "Admin",
+					OutputDir:  filepath.Join(tempDir, "admin"),
+					Package:    string(ModuleFolder),
+					Release:    "1-2",
+				},
+			},
+		},
+		{
+			name: "Every versioned struct of a package",
+			config: Config{
+				Path:   configPath,
+				Models: []ModelConfig{{Source: "models"}},
+			},
+			want: []BatchTarget{
+				{
+					Filename:   filepath.Join(tempDir, "models", "user.go"),
+					StructName: "User",
+					Package:    string(ModuleFolder),
+				},
+			},
+		},
+		{
+			name: "Struct not found",
+			config: Config{
+				Path:   configPath,
+				Models: []ModelConfig{{Source: "models", Struct: "Untagged"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Missing source",
+			config: Config{
+				Path:   configPath,
+				Models: []ModelConfig{{Struct: "User"}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets, err := tt.config.Targets(false)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, targets)
+		})
+	}
+}
+
+func TestConfig_Generate(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	writeBatchFixture(t, tempDir)
+	configPath := filepath.Join(tempDir, ConfigFileName)
+	assert.NoError(t, os.WriteFile(configPath, []byte("package: api\nmodels:\n  - source: ./models/...\n"), 0644))
+
+	resolver := Resolver{}
+	assert.NoError(t, resolver.FindConfigPath(filepath.Join(tempDir, "models", "admin")))
+	assert.Equal(t, configPath, resolver.ConfigPath)
+
+	config, err := LoadConfig(resolver.ConfigPath)
+	assert.NoError(t, err)
+	targets, err := config.Targets(false)
+	assert.NoError(t, err)
+
+	batch := Batch{}
+	_, err = batch.Generate(targets)
+	assert.NoError(t, err)
+
+	hub, err := os.ReadFile(filepath.Join(tempDir, "models", "api", "user.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(hub), "package api")
+	assert.Contains(t, string(hub), `"example.com/batch/models/api/user"`)
+	assert.FileExists(t, filepath.Join(tempDir, "models", "admin", "api", "admin.go"))
+	assert.FileExists(t, filepath.Join(tempDir, "models", "admin", "api", "types.go"))
+}
@@ -0,0 +1,105 @@
+package conversor
+
+import (
+	"fmt"
+	"github.com/gerardforcada/structera/interfaces"
+	"reflect"
+	"strings"
+)
+
+// LostField is a hub field that is set but that the target of a conversion has no field for.
+type LostField struct {
+	Name string // Go field name
+	Key  string // JSON key, empty for the fields JSON skips
+}
+
+// LossError lists the hub fields a conversion drops. Lost returns it as a
+// warning, and ToEraStrict fails with it.
+type LossError struct {
+	Target string
+	Fields []LostField
+}
+
+func (e *LossError) Error() string {
+	names := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		names = append(names, field.Name)
+	}
+	return fmt.Sprintf("%s has no field for the hub fields %s", e.Target, strings.Join(names, ", "))
+}
+
+// Lost returns the hub fields that are set but that the target has no field
+// for, or nil when converting the hub to the target keeps every value. The
+// target is what ToEra takes: a pointer to an era or to an interfaces.Era.
+// Targets that aren't structs, such as maps, lose nothing.
+func Lost(target any, hub interfaces.Hub) *LossError {
+	if hub == nil {
+		return nil
+	}
+
+	targetType := structType(target)
+	base := reflect.ValueOf(hub.GetBaseStruct())
+	if targetType == nil || base.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var lost []LostField
+	for i := 0; i < base.NumField(); i++ {
+		field := base.Type().Field(i)
+		if !field.IsExported() || base.Field(i).IsZero() {
+			continue
+		}
+		if _, ok := targetType.FieldByName(field.Name); !ok {
+			lost = append(lost, LostField{Name: field.Name, Key: jsonKey(field)})
+		}
+	}
+
+	if len(lost) == 0 {
+		return nil
+	}
+	return &LossError{Target: targetType.String(), Fields: lost}
+}
+
+// ToEraStrict fills the target with the hub content like hub.ToEra, but fails
+// with a *LossError, leaving the target untouched, when the target has no
+// field for some of the hub fields that are set.
+func ToEraStrict(target any, hub interfaces.Hub) error {
+	if hub == nil {
+		return fmt.Errorf("no hub to convert")
+	}
+	if lost := Lost(target, hub); lost != nil {
+		return lost
+	}
+	return hub.ToEra(target)
+}
+
+// structType returns the struct type behind the pointers and interfaces of the target, if any.
+func structType(target any) reflect.Type {
+	value := reflect.ValueOf(target)
+	for (value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface) && !value.IsNil() {
+		value = value.Elem()
+	}
+	if !value.IsValid() || value.Kind() == reflect.Interface {
+		return nil
+	}
+
+	valueType := value.Type()
+	for valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+	if valueType.Kind() != reflect.Struct {
+		return nil
+	}
+	return valueType
+}
+
+func jsonKey(field reflect.StructField) string {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+	if name := strings.Split(tag, ",")[0]; name != "" {
+		return name
+	}
+	return field.Name
+}
@@ -0,0 +1,140 @@
+package conversor
+
+import (
+	"errors"
+	"github.com/gerardforcada/structera/interfaces"
+	"reflect"
+	"testing"
+)
+
+type lossBase struct {
+	Name    *string `json:"name"`
+	OnlyIn1 *int    `json:"only_in_1"`
+	Secret  *string `json:"-"`
+	Plain   *bool
+	hidden  *int
+}
+
+type lossV1 struct {
+	Name    string `json:"name"`
+	OnlyIn1 int    `json:"only_in_1"`
+}
+
+func (era lossV1) GetName() string { return "loss" }
+func (era lossV1) GetVersion() int { return 1 }
+
+type lossV2 struct {
+	Name string `json:"name"`
+}
+
+func (era lossV2) GetName() string { return "loss" }
+func (era lossV2) GetVersion() int { return 2 }
+
+func TestLost(t *testing.T) {
+	name, onlyIn1, secret, plain, hidden := "hey", 1, "secret", true, 2
+	hub := mockHub{baseStruct: lossBase{Name: &name, OnlyIn1: &onlyIn1, Secret: &secret, Plain: &plain, hidden: &hidden}}
+	onlyName := mockHub{baseStruct: lossBase{Name: &name}}
+
+	var v1 lossV1
+	var v2 lossV2
+	var era interfaces.Era = lossV2{}
+	var pointerEra interfaces.Era = &lossV2{}
+	var nilEra interfaces.Era
+
+	tests := []struct {
+		name   string
+		target any
+		hub    interfaces.Hub
+		want   *LossError
+	}{
+		{
+			name:   "Fields the era doesn't have",
+			target: &v2,
+			hub:    hub,
+			want: &LossError{Target: "conversor.lossV2", Fields: []LostField{
+				{Name: "OnlyIn1", Key: "only_in_1"},
+				{Name: "Secret", Key: ""},
+				{Name: "Plain", Key: "Plain"},
+			}},
+		},
+		{
+			name:   "Era behind the interface",
+			target: &era,
+			hub:    hub,
+			want: &LossError{Target: "conversor.lossV2", Fields: []LostField{
+				{Name: "OnlyIn1", Key: "only_in_1"},
+				{Name: "Secret", Key: ""},
+				{Name: "Plain", Key: "Plain"},
+			}},
+		},
+		{
+			name:   "Pointer era behind the interface",
+			target: &pointerEra,
+			hub:    onlyName,
+			want:   nil,
+		},
+		{
+			name:   "Unset fields aren't lost",
+			target: &v1,
+			hub:    mockHub{baseStruct: lossBase{Name: &name, OnlyIn1: &onlyIn1}},
+			want:   nil,
+		},
+		{
+			name:   "Maps keep every field",
+			target: &map[string]any{},
+			hub:    hub,
+			want:   nil,
+		},
+		{
+			name:   "Empty interface",
+			target: &nilEra,
+			hub:    hub,
+			want:   nil,
+		},
+		{
+			name:   "No hub",
+			target: &v2,
+			hub:    nil,
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Lost(tt.target, tt.hub); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lost() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToEraStrict(t *testing.T) {
+	name, onlyIn1 := "hey", 1
+	hub := mockHub{baseStruct: lossBase{Name: &name, OnlyIn1: &onlyIn1}}
+
+	t.Run("Lossy", func(t *testing.T) {
+		var era lossV2
+		err := ToEraStrict(&era, hub)
+		var lossErr *LossError
+		if !errors.As(err, &lossErr) || len(lossErr.Fields) != 1 || lossErr.Fields[0].Name != "OnlyIn1" {
+			t.Fatalf("Expected a loss of OnlyIn1, got %v", err)
+		}
+		if err.Error() != "conversor.lossV2 has no field for the hub fields OnlyIn1" {
+			t.Errorf("Unexpected error message: %v", err)
+		}
+	})
+
+	t.Run("Lossless", func(t *testing.T) {
+		var era lossV1
+		if err := ToEraStrict(&era, hub); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("NoHub", func(t *testing.T) {
+		var era lossV1
+		if err := ToEraStrict(&era, nil); err == nil {
+			t.Error("Expected error for a nil hub, got none")
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package conversor
+
+import (
+	"fmt"
+	"github.com/gerardforcada/structera/interfaces"
+)
+
+// Migrate moves the era to the target version one adjacent era at a time,
+// with upgrade moving an era to the next version and downgrade to the
+// previous one.
+func Migrate(from interfaces.Era, toVersion int, upgrade, downgrade func(interfaces.Era) (interfaces.Era, error)) (interfaces.Era, error) {
+	if from == nil {
+		return nil, fmt.Errorf("no era to migrate")
+	}
+
+	era := from
+	for era.GetVersion() != toVersion {
+		step := upgrade
+		if era.GetVersion() > toVersion {
+			step = downgrade
+		}
+
+		next, err := step(era)
+		if err != nil {
+			return nil, fmt.Errorf("error migrating from version %d: %w", era.GetVersion(), err)
+		}
+
+		// Every step has to get closer to the target, or the chain would never end
+		if distance(next.GetVersion(), toVersion) >= distance(era.GetVersion(), toVersion) {
+			return nil, fmt.Errorf("error migrating from version %d: version %d doesn't get closer to version %d", era.GetVersion(), next.GetVersion(), toVersion)
+		}
+		era = next
+	}
+
+	return era, nil
+}
+
+// Hooks picks the hook of a migration step out of each set of hooks, for
+// the generated Migrate methods to pass them to the step.
+func Hooks[S any, H any](sets []S, hook func(S) H) []H {
+	hooks := make([]H, 0, len(sets))
+	for _, set := range sets {
+		hooks = append(hooks, hook(set))
+	}
+	return hooks
+}
+
+func distance(a, b int) int {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
@@ -0,0 +1,87 @@
+package conversor
+
+import (
+	"fmt"
+	"github.com/gerardforcada/structera/interfaces"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	var steps []string
+	upgrade := func(era interfaces.Era) (interfaces.Era, error) {
+		steps = append(steps, fmt.Sprintf("up %d", era.GetVersion()))
+		if era.GetVersion() == 9 {
+			return nil, fmt.Errorf("no version 10")
+		}
+		return mockEra{Name: "test", Version: era.GetVersion() + 1}, nil
+	}
+	downgrade := func(era interfaces.Era) (interfaces.Era, error) {
+		steps = append(steps, fmt.Sprintf("down %d", era.GetVersion()))
+		return mockEra{Name: "test", Version: era.GetVersion() - 1}, nil
+	}
+
+	t.Run("Upgrade", func(t *testing.T) {
+		steps = nil
+		era, err := Migrate(mockEra{Name: "test", Version: 1}, 3, upgrade, downgrade)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if era.GetVersion() != 3 || fmt.Sprint(steps) != "[up 1 up 2]" {
+			t.Errorf("Expected version 3 through [up 1 up 2], got %d through %v", era.GetVersion(), steps)
+		}
+	})
+
+	t.Run("Downgrade", func(t *testing.T) {
+		steps = nil
+		era, err := Migrate(mockEra{Name: "test", Version: 4}, 2, upgrade, downgrade)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if era.GetVersion() != 2 || fmt.Sprint(steps) != "[down 4 down 3]" {
+			t.Errorf("Expected version 2 through [down 4 down 3], got %d through %v", era.GetVersion(), steps)
+		}
+	})
+
+	t.Run("SameVersion", func(t *testing.T) {
+		steps = nil
+		from := mockEra{Name: "test", Version: 2}
+		era, err := Migrate(from, 2, upgrade, downgrade)
+		if err != nil || era != from || len(steps) != 0 {
+			t.Errorf("Expected the same era without steps, got %v, %v and %v", era, err, steps)
+		}
+	})
+
+	t.Run("StepError", func(t *testing.T) {
+		_, err := Migrate(mockEra{Name: "test", Version: 8}, 11, upgrade, downgrade)
+		if err == nil || err.Error() != "error migrating from version 9: no version 10" {
+			t.Errorf("Expected the error of the failing step, got %v", err)
+		}
+	})
+
+	t.Run("StepNotCloser", func(t *testing.T) {
+		stuck := func(era interfaces.Era) (interfaces.Era, error) {
+			return era, nil
+		}
+		_, err := Migrate(mockEra{Name: "test", Version: 1}, 2, stuck, stuck)
+		if err == nil {
+			t.Error("Expected error for a step that doesn't get closer, got none")
+		}
+	})
+
+	t.Run("NilEra", func(t *testing.T) {
+		if _, err := Migrate(nil, 1, upgrade, downgrade); err == nil {
+			t.Error("Expected error for nil era, got none")
+		}
+	})
+}
+
+func TestHooks(t *testing.T) {
+	type hooks struct{ Step string }
+	got := Hooks([]hooks{{Step: "a"}, {}, {Step: "b"}}, func(h hooks) string { return h.Step })
+	if fmt.Sprint(got) != "[a  b]" {
+		t.Errorf("Hooks() = %q, want [a  b]", got)
+	}
+	if got := Hooks(nil, func(h hooks) string { return h.Step }); len(got) != 0 {
+		t.Errorf("Hooks() = %q, want none", got)
+	}
+}
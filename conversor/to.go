@@ -0,0 +1,87 @@
+package conversor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gerardforcada/structera/interfaces"
+	"reflect"
+)
+
+// ConversionError is the error To and ToVersion fail with. Field is the era
+// field that failed, when the conversion tells which one.
+type ConversionError struct {
+	Hub   string
+	Era   string
+	Field string
+	Err   error
+}
+
+func (e *ConversionError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("error converting hub %s to %s, field %s: %v", e.Hub, e.Era, e.Field, e.Err)
+	}
+	return fmt.Sprintf("error converting hub %s to %s: %v", e.Hub, e.Era, e.Err)
+}
+
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+// To returns the hub content as an era of type E, e.g. conversor.To[user.V3](hub).
+// E is the era struct itself: interfaces and pointers to eras are rejected, use
+// ToVersion when the era is only known at runtime.
+func To[E interfaces.Era](hub interfaces.Hub) (E, error) {
+	var era E
+	eraType := reflect.TypeOf((*E)(nil)).Elem()
+	if hub == nil {
+		return era, &ConversionError{Hub: "<nil>", Era: eraType.String(), Err: fmt.Errorf("no hub to convert")}
+	}
+	if eraType.Kind() == reflect.Interface || eraType.Kind() == reflect.Ptr {
+		// Their zero value holds no era to convert into
+		return era, &ConversionError{Hub: typeName(hub), Era: eraType.String(), Err: fmt.Errorf("the era must be a struct type, not an interface or a pointer")}
+	}
+
+	if err := hub.ToEra(&era); err != nil {
+		return era, newConversionError(hub, typeName(era), err)
+	}
+	return era, nil
+}
+
+// ToVersion returns the hub content as its era of the version.
+func ToVersion(hub interfaces.Hub, version int) (interfaces.Era, error) {
+	if hub == nil {
+		return nil, &ConversionError{Hub: "<nil>", Era: fmt.Sprintf("version %d", version), Err: fmt.Errorf("no hub to convert")}
+	}
+
+	era, err := hub.GetEraFromVersion(version)
+	if err != nil {
+		return nil, newConversionError(hub, fmt.Sprintf("version %d", version), err)
+	}
+	if err := hub.ToEra(&era); err != nil {
+		return nil, newConversionError(hub, typeName(era), err)
+	}
+	return era, nil
+}
+
+func newConversionError(hub interfaces.Hub, era string, err error) *ConversionError {
+	conversionErr := &ConversionError{Hub: typeName(hub), Era: era, Err: err}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		conversionErr.Field = typeErr.Field
+	}
+	return conversionErr
+}
+
+// typeName names the type behind the value without its pointers, e.g. user.V3.
+func typeName(value any) string {
+	valueType := reflect.TypeOf(value)
+	if valueType == nil {
+		return "<nil>"
+	}
+	for valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+	return valueType.String()
+}
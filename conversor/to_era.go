@@ -15,19 +15,23 @@ func ToEra(target any, hub interfaces.Hub) error {
 
 	hubJSON, err := json.Marshal(hub)
 	if err != nil {
-		return fmt.Errorf("error marshaling hub: %v", err)
+		return fmt.Errorf("error marshaling hub: %w", err)
 	}
 
 	if _, ok := target.(*interfaces.Era); ok {
 		eraType := reflect.TypeOf(target).Elem()
 		if eraType.Kind() == reflect.Interface {
-			eraType = reflect.ValueOf(target).Elem().Elem().Type()
+			era := reflect.ValueOf(target).Elem()
+			if era.IsNil() {
+				return fmt.Errorf("target era must hold the era to fill, got nil")
+			}
+			eraType = era.Elem().Type()
 		}
 
 		newInstance := reflect.New(eraType).Interface()
 		err = json.Unmarshal(hubJSON, newInstance)
 		if err != nil {
-			return fmt.Errorf("error unmarshaling into era: %v", err)
+			return fmt.Errorf("error unmarshaling into era: %w", err)
 		}
 
 		reflect.ValueOf(target).Elem().Set(reflect.ValueOf(newInstance).Elem())
@@ -36,7 +40,7 @@ func ToEra(target any, hub interfaces.Hub) error {
 
 	err = json.Unmarshal(hubJSON, target)
 	if err != nil {
-		return fmt.Errorf("error unmarshaling into target: %v", err)
+		return fmt.Errorf("error unmarshaling into target: %w", err)
 	}
 
 	return nil
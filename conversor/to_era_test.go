@@ -23,8 +23,8 @@ func (m mockHub) GetMaxVersion() int {
 	return m.maxVersion
 }
 
-func (m mockHub) DetectVersion() int {
-	return m.detectedVer
+func (m mockHub) DetectVersion() (int, error) {
+	return m.detectedVer, nil
 }
 
 func (m mockHub) GetEraFromVersion(version int) (interfaces.Era, error) {
@@ -55,6 +55,10 @@ func (m mockHub) ToEra(target any) error {
 	return nil
 }
 
+func (m mockHub) FromEra(era interfaces.Era) error {
+	return nil
+}
+
 type mockEra struct {
 	Name    string
 	Version int
@@ -105,6 +109,14 @@ func TestToEra(t *testing.T) {
 		}
 	})
 
+	t.Run("NilEraTarget", func(t *testing.T) {
+		var era interfaces.Era
+		err := ToEra(&era, mockHub1)
+		if err == nil {
+			t.Error("Expected error for an era target holding no era, got none")
+		}
+	})
+
 	t.Run("NoValidHub", func(t *testing.T) {
 		var era interfaces.Era = mockEra{}
 		err := ToEra(&era, nil)
@@ -0,0 +1,104 @@
+package conversor
+
+import (
+	"errors"
+	"github.com/gerardforcada/structera/interfaces"
+	"strings"
+	"testing"
+)
+
+// jsonHub converts through JSON like the hubs do for targets that aren't their eras
+type jsonHub struct {
+	mockHub
+	Name  string `json:"name"`
+	Count any    `json:"count"`
+}
+
+func (h jsonHub) ToEra(target any) error {
+	return ToEra(target, h)
+}
+
+type countEra struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func (era countEra) GetName() string { return "count" }
+func (era countEra) GetVersion() int { return 1 }
+
+func TestTo(t *testing.T) {
+	t.Run("ValidConversion", func(t *testing.T) {
+		era, err := To[countEra](jsonHub{Name: "hey", Count: 3})
+		if err != nil || era != (countEra{Name: "hey", Count: 3}) {
+			t.Errorf("Expected {hey 3} and no error, got %+v and %v", era, err)
+		}
+	})
+
+	t.Run("FailingField", func(t *testing.T) {
+		_, err := To[countEra](jsonHub{Count: "many"})
+		var conversionErr *ConversionError
+		if !errors.As(err, &conversionErr) {
+			t.Fatalf("Expected a ConversionError, got %v", err)
+		}
+		if conversionErr.Hub != "conversor.jsonHub" || conversionErr.Era != "conversor.countEra" || conversionErr.Field != "count" {
+			t.Errorf("Expected the hub, era and field in %+v", conversionErr)
+		}
+		if !strings.HasPrefix(err.Error(), "error converting hub conversor.jsonHub to conversor.countEra, field count: ") {
+			t.Errorf("Unexpected error message: %v", err)
+		}
+	})
+
+	t.Run("NotAStruct", func(t *testing.T) {
+		var conversionErr *ConversionError
+		if _, err := To[interfaces.Era](jsonHub{Name: "hey"}); !errors.As(err, &conversionErr) || conversionErr.Era != "interfaces.Era" {
+			t.Errorf("Expected a ConversionError for interfaces.Era, got %v", err)
+		}
+		if _, err := To[*countEra](jsonHub{Name: "hey"}); !errors.As(err, &conversionErr) || conversionErr.Era != "*conversor.countEra" {
+			t.Errorf("Expected a ConversionError for *conversor.countEra, got %v", err)
+		}
+	})
+
+	t.Run("NoHub", func(t *testing.T) {
+		if _, err := To[countEra](nil); err == nil {
+			t.Error("Expected error for a nil hub, got none")
+		}
+	})
+}
+
+func TestToVersion(t *testing.T) {
+	hub := jsonHub{
+		mockHub: mockHub{eras: map[int]interfaces.Era{1: countEra{}}},
+		Name:    "hey",
+		Count:   3,
+	}
+
+	t.Run("ValidConversion", func(t *testing.T) {
+		era, err := ToVersion(hub, 1)
+		if err != nil || era != (countEra{Name: "hey", Count: 3}) {
+			t.Errorf("Expected {hey 3} and no error, got %+v and %v", era, err)
+		}
+	})
+
+	t.Run("UnknownVersion", func(t *testing.T) {
+		_, err := ToVersion(hub, 2)
+		var conversionErr *ConversionError
+		if !errors.As(err, &conversionErr) || conversionErr.Era != "version 2" {
+			t.Errorf("Expected a ConversionError for version 2, got %v", err)
+		}
+	})
+
+	t.Run("FailingField", func(t *testing.T) {
+		hub.Count = "many"
+		_, err := ToVersion(hub, 1)
+		var conversionErr *ConversionError
+		if !errors.As(err, &conversionErr) || conversionErr.Era != "conversor.countEra" || conversionErr.Field != "count" {
+			t.Errorf("Expected a ConversionError on conversor.countEra.count, got %v", err)
+		}
+	})
+
+	t.Run("NoHub", func(t *testing.T) {
+		if _, err := ToVersion(nil, 1); err == nil {
+			t.Error("Expected error for a nil hub, got none")
+		}
+	})
+}
@@ -46,8 +46,8 @@ func (d MockEntity) GetBaseStruct() any {
 	return d.MockEntityAllFields
 }
 
-func (d MockEntity) DetectVersion() int {
-	return BestMatchingEra[MockEntity](d)
+func (d MockEntity) DetectVersion() (int, error) {
+	return Detect[MockEntity](d)
 }
 
 func (d MockEntity) GetVersions() []int {
@@ -61,6 +61,10 @@ func (d MockEntity) ToEra(target any) error {
 	return conversor.ToEra(target, d)
 }
 
+func (d MockEntity) FromEra(era interfaces.Era) error {
+	return nil
+}
+
 func (d MockEntity) GetMinVersion() int {
 	return Version1
 }
@@ -0,0 +1,154 @@
+package detector
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gerardforcada/structera/interfaces"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// jsonField is a key a struct takes in JSON, and the type of its field.
+type jsonField struct {
+	key       string
+	fieldType reflect.Type
+}
+
+// FromJSON detects the era of a JSON object from its keys, without decoding
+// it into the hub. It returns the lowest version whose era has every key of
+// the object, and of the objects held by its struct fields, and fails when
+// no era has all of them. Keys set to null are present all the same, and the
+// values aren't checked against the field types.
+func FromJSON(data []byte, hub interfaces.Hub) (int, error) {
+	ranking, err := RankJSON(data, hub)
+	if err != nil {
+		return 0, err
+	}
+	return ranking.detect("key of the JSON")
+}
+
+// RankJSON scores every era of the hub against the keys of a JSON object,
+// like Rank does with the fields set in the hub. Nested keys are named after
+// their parents, e.g. address.city.
+func RankJSON(data []byte, hub interfaces.Hub) (Ranking, error) {
+	if hub == nil {
+		return Ranking{}, fmt.Errorf("no hub to detect the era of")
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return Ranking{}, fmt.Errorf("error reading the JSON keys: %w", err)
+	}
+
+	var ranking Ranking
+	for _, era := range hub.GetVersionStructs() {
+		score := EraScore{Version: era.GetVersion()}
+		matchKeys(&score, object, reflect.TypeOf(era), "")
+		score.Score = len(score.Matched) - len(score.Missing)
+		ranking.Eras = append(ranking.Eras, score)
+	}
+
+	ranking.sort()
+	return ranking, nil
+}
+
+// matchKeys sorts the keys of the object into the ones the struct takes and
+// the ones it lacks, going into the objects its struct fields hold.
+func matchKeys(score *EraScore, object map[string]json.RawMessage, structType reflect.Type, prefix string) {
+	fields := jsonFields(structType)
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	used := make(map[string]bool)
+	for _, key := range keys {
+		field, ok := findJSONField(fields, key)
+		if !ok {
+			score.Missing = append(score.Missing, prefix+key)
+			continue
+		}
+		used[field.key] = true
+		score.Matched = append(score.Matched, prefix+key)
+
+		if nestedType := nestedStruct(field.fieldType); nestedType != nil {
+			var nested map[string]json.RawMessage
+			if err := json.Unmarshal(object[key], &nested); err == nil && nested != nil {
+				matchKeys(score, nested, nestedType, prefix+key+".")
+			}
+		}
+	}
+
+	for _, field := range fields {
+		if !used[field.key] {
+			score.Extraneous = append(score.Extraneous, prefix+field.key)
+		}
+	}
+}
+
+// jsonFields lists the keys encoding/json reads into the struct, including
+// the ones of its embedded structs.
+func jsonFields(structType reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		if field.Anonymous && name == "" {
+			embeddedType := field.Type
+			if embeddedType.Kind() == reflect.Ptr {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct {
+				fields = append(fields, jsonFields(embeddedType)...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, jsonField{key: name, fieldType: field.Type})
+	}
+	return fields
+}
+
+// findJSONField matches the key like encoding/json does: exactly first, then
+// ignoring case.
+func findJSONField(fields []jsonField, key string) (jsonField, bool) {
+	for _, field := range fields {
+		if field.key == key {
+			return field, true
+		}
+	}
+	for _, field := range fields {
+		if strings.EqualFold(field.key, key) {
+			return field, true
+		}
+	}
+	return jsonField{}, false
+}
+
+// nestedStruct returns the struct type of a field whose keys are worth
+// checking, that is a struct decoded field by field.
+func nestedStruct(fieldType reflect.Type) reflect.Type {
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType.Kind() != reflect.Struct || reflect.PtrTo(fieldType).Implements(unmarshalerType) {
+		return nil
+	}
+	return fieldType
+}
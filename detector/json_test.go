@@ -0,0 +1,81 @@
+package detector
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFromJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    int
+		wantErr string
+	}{
+		{
+			name: "Key only one era has",
+			data: `{"in_every_version":"hey","from_2_to_end":2}`,
+			want: Version2,
+		},
+		{
+			name: "Keys set to null are present",
+			data: `{"in_every_version":"hey","only_in_1":null}`,
+			want: Version1,
+		},
+		{
+			name: "Values aren't decoded",
+			data: `{"from_2_to_end":"not a number"}`,
+			want: Version2,
+		},
+		{
+			name: "Keys match ignoring case",
+			data: `{"In_Every_Version":"hey"}`,
+			want: Version1,
+		},
+		{
+			name: "Empty object",
+			data: `{}`,
+			want: Version1,
+		},
+		{
+			name:    "No era has every key",
+			data:    `{"only_in_1":1,"from_2_to_end":2,"unknown":true}`,
+			wantErr: "no era has every key of the JSON, the closest one, version 1, lacks from_2_to_end, unknown",
+		},
+		{
+			name:    "Not an object",
+			data:    `[1, 2]`,
+			wantErr: "error reading the JSON keys: ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromJSON([]byte(tt.data), MockEntity{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Errorf("FromJSON() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("FromJSON() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRankJSON(t *testing.T) {
+	got, err := RankJSON([]byte(`{"in_every_version":"hey","only_in_1":null}`), MockEntity{})
+	want := Ranking{Eras: []EraScore{
+		{Version: Version1, Score: 2, Matched: []string{"in_every_version", "only_in_1"}},
+		{Version: Version2, Score: 0, Matched: []string{"in_every_version"}, Missing: []string{"only_in_1"}, Extraneous: []string{"from_2_to_end"}},
+	}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("RankJSON() = %+v, %v, want %+v", got, err, want)
+	}
+
+	if _, err := RankJSON([]byte(`{}`), nil); err == nil {
+		t.Error("Expected error for a nil hub, got none")
+	}
+}
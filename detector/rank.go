@@ -0,0 +1,128 @@
+package detector
+
+import (
+	"fmt"
+	"github.com/gerardforcada/structera/interfaces"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// EraScore tells how well the fields set in a hub fit an era.
+type EraScore struct {
+	Version    int
+	Score      int      // Matched fields minus missing fields
+	Matched    []string // Set hub fields the era has
+	Missing    []string // Set hub fields the era lacks, their values would be lost
+	Extraneous []string // Era fields the hub doesn't set
+}
+
+// Complete reports whether the era has every field set in the hub.
+func (s EraScore) Complete() bool {
+	return len(s.Missing) == 0
+}
+
+// Ranking holds every era of a hub, best fit first. Ambiguous is set when no
+// era has every set field and the closest one doesn't fit better than the
+// next one, so it was only picked for its lower version. When some eras have
+// every set field, the lowest of them is the detected one by design, and the
+// ranking isn't ambiguous.
+type Ranking struct {
+	Eras      []EraScore
+	Ambiguous bool
+}
+
+// Rank scores every era of the hub against the fields the hub sets. Eras
+// missing fewer set fields go first, then the ones matching more of them,
+// then the lowest versions.
+func Rank[T interfaces.Hub](hub T) Ranking {
+	base := reflect.ValueOf(hub.GetBaseStruct())
+	baseType := base.Type()
+
+	var ranking Ranking
+	for _, era := range hub.GetVersionStructs() {
+		eraType := reflect.TypeOf(era)
+		score := EraScore{Version: era.GetVersion()}
+		matchedEraFields := make(map[string]bool)
+
+		for i := 0; i < baseType.NumField(); i++ {
+			baseField := baseType.Field(i)
+			if baseField.Type.Kind() == reflect.Ptr && base.Field(i).IsNil() {
+				continue
+			}
+
+			if eraField, ok := eraType.FieldByName(baseField.Name); ok && fieldMatches(baseField, eraField) {
+				score.Matched = append(score.Matched, baseField.Name)
+				matchedEraFields[eraField.Name] = true
+			} else {
+				score.Missing = append(score.Missing, baseField.Name)
+			}
+		}
+
+		for i := 0; i < eraType.NumField(); i++ {
+			if name := eraType.Field(i).Name; !matchedEraFields[name] {
+				score.Extraneous = append(score.Extraneous, name)
+			}
+		}
+
+		score.Score = len(score.Matched) - len(score.Missing)
+		ranking.Eras = append(ranking.Eras, score)
+	}
+
+	ranking.sort()
+	return ranking
+}
+
+// Detect returns the lowest version whose era has every field set in the
+// hub, and fails when no era has all of them.
+func Detect[T interfaces.Hub](hub T) (int, error) {
+	return Rank(hub).detect("field set in the hub")
+}
+
+// sort puts the eras missing fewer fields first, then the ones matching
+// more of them, then the lowest versions, and flags a tie for the first place
+// between eras lacking set fields.
+func (r *Ranking) sort() {
+	sort.SliceStable(r.Eras, func(i, j int) bool {
+		a, b := r.Eras[i], r.Eras[j]
+		if len(a.Missing) != len(b.Missing) {
+			return len(a.Missing) < len(b.Missing)
+		}
+		if len(a.Matched) != len(b.Matched) {
+			return len(a.Matched) > len(b.Matched)
+		}
+		return a.Version < b.Version
+	})
+
+	if len(r.Eras) > 1 {
+		first, second := r.Eras[0], r.Eras[1]
+		r.Ambiguous = !first.Complete() && len(first.Missing) == len(second.Missing) && len(first.Matched) == len(second.Matched)
+	}
+}
+
+// detect returns the version of the best era, when it has everything the content sets.
+func (r Ranking) detect(content string) (int, error) {
+	if len(r.Eras) == 0 {
+		return 0, fmt.Errorf("hub has no eras")
+	}
+
+	best := r.Eras[0]
+	if !best.Complete() {
+		return 0, incompleteError(content, best.Version, best.Missing)
+	}
+	return best.Version, nil
+}
+
+func incompleteError(content string, closest int, missing []string) error {
+	return fmt.Errorf("no era has every %s, the closest one, version %d, lacks %s", content, closest, strings.Join(missing, ", "))
+}
+
+// fieldMatches reports whether the era field holds the hub field.
+func fieldMatches(baseField, eraField reflect.StructField) bool {
+	baseFieldType := baseField.Type
+	if baseFieldType.Kind() == reflect.Ptr {
+		baseFieldType = baseFieldType.Elem()
+	}
+	// Embedded pointers are not wrapped in another pointer in the hub
+	return baseFieldType == eraField.Type || (baseField.Anonymous && baseField.Type == eraField.Type)
+}
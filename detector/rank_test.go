@@ -0,0 +1,99 @@
+package detector
+
+import (
+	"github.com/aws/smithy-go/ptr"
+	"reflect"
+	"testing"
+)
+
+func TestRank(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields MockEntityAllFields
+		want   Ranking
+	}{
+		{
+			name:   "Only one era has every set field",
+			fields: MockEntityAllFields{InEveryVersion: ptr.String("hey"), OnlyIn1: ptr.Int(1)},
+			want: Ranking{Eras: []EraScore{
+				{Version: Version1, Score: 2, Matched: []string{"InEveryVersion", "OnlyIn1"}},
+				{Version: Version2, Score: 0, Matched: []string{"InEveryVersion"}, Missing: []string{"OnlyIn1"}, Extraneous: []string{"From2ToEnd"}},
+			}},
+		},
+		{
+			name:   "Every era has the set fields",
+			fields: MockEntityAllFields{InEveryVersion: ptr.String("hey")},
+			want: Ranking{Eras: []EraScore{
+				{Version: Version1, Score: 1, Matched: []string{"InEveryVersion"}, Extraneous: []string{"OnlyIn1"}},
+				{Version: Version2, Score: 1, Matched: []string{"InEveryVersion"}, Extraneous: []string{"From2ToEnd"}},
+			}},
+		},
+		{
+			name:   "No era has every set field",
+			fields: MockEntityAllFields{OnlyIn1: ptr.Int(1), From2ToEnd: ptr.Uint8(2)},
+			want: Ranking{Eras: []EraScore{
+				{Version: Version1, Score: 0, Matched: []string{"OnlyIn1"}, Missing: []string{"From2ToEnd"}, Extraneous: []string{"InEveryVersion"}},
+				{Version: Version2, Score: 0, Matched: []string{"From2ToEnd"}, Missing: []string{"OnlyIn1"}, Extraneous: []string{"InEveryVersion"}},
+			}, Ambiguous: true},
+		},
+		{
+			name:   "Fewer missing fields go first",
+			fields: MockEntityAllFields{InEveryVersion: ptr.String("hey"), From2ToEnd: ptr.Uint8(2)},
+			want: Ranking{Eras: []EraScore{
+				{Version: Version2, Score: 2, Matched: []string{"InEveryVersion", "From2ToEnd"}},
+				{Version: Version1, Score: 0, Matched: []string{"InEveryVersion"}, Missing: []string{"From2ToEnd"}, Extraneous: []string{"OnlyIn1"}},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Rank[MockEntity](MockEntity{MockEntityAllFields: tt.fields}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Rank() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  MockEntityAllFields
+		want    int
+		wantErr string
+	}{
+		{
+			name:   "Only era with every set field",
+			fields: MockEntityAllFields{InEveryVersion: ptr.String("hey"), From2ToEnd: ptr.Uint8(2)},
+			want:   Version2,
+		},
+		{
+			name:   "Lowest era with every set field",
+			fields: MockEntityAllFields{InEveryVersion: ptr.String("hey")},
+			want:   Version1,
+		},
+		{
+			name:   "Nothing set",
+			fields: MockEntityAllFields{},
+			want:   Version1,
+		},
+		{
+			name:    "No era has every set field",
+			fields:  MockEntityAllFields{OnlyIn1: ptr.Int(1), From2ToEnd: ptr.Uint8(2)},
+			wantErr: "no era has every field set in the hub, the closest one, version 1, lacks From2ToEnd",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MockEntity{MockEntityAllFields: tt.fields}.DetectVersion()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("DetectVersion() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("DetectVersion() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package detector
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Bitset is a set of hub fields, bit i standing for the field i of the hub.
+type Bitset []uint64
+
+// NewBitset returns an empty set for a hub with the given number of fields.
+func NewBitset(fields int) Bitset {
+	return make(Bitset, (fields+63)/64)
+}
+
+func (b Bitset) Add(field int) {
+	b[field/64] |= 1 << (field % 64)
+}
+
+func (b Bitset) Has(field int) bool {
+	return field/64 < len(b) && b[field/64]&(1<<(field%64)) != 0
+}
+
+// EraFields is the set of hub fields an era has.
+type EraFields struct {
+	Version int
+	Fields  Bitset
+}
+
+// FieldTable tells which hub fields each era has. The generator writes one
+// per hub, so detecting the era of a hub is a comparison of bitsets instead
+// of a walk over the fields with reflection.
+type FieldTable struct {
+	Fields []string // The hub fields, in the order of their bits
+	Eras   []EraFields
+}
+
+// Detect returns the lowest version whose era has every field of the set,
+// and fails when no era has all of them, like Detect does with reflection.
+func (t FieldTable) Detect(set Bitset) (int, error) {
+	if len(t.Eras) == 0 {
+		return 0, fmt.Errorf("hub has no eras")
+	}
+
+	// Same order as Rank: fewer missing fields, then more matched fields, then the lowest version
+	best, bestMissing, bestMatched := EraFields{}, -1, 0
+	for _, era := range t.Eras {
+		missing, matched := 0, 0
+		for i, word := range set {
+			var eraWord uint64
+			if i < len(era.Fields) {
+				eraWord = era.Fields[i]
+			}
+			missing += bits.OnesCount64(word &^ eraWord)
+			matched += bits.OnesCount64(word & eraWord)
+		}
+
+		better := bestMissing < 0 || missing < bestMissing ||
+			(missing == bestMissing && (matched > bestMatched || (matched == bestMatched && era.Version < best.Version)))
+		if better {
+			best, bestMissing, bestMatched = era, missing, matched
+		}
+	}
+
+	if bestMissing > 0 {
+		var missing []string
+		for i, name := range t.Fields {
+			if set.Has(i) && !best.Fields.Has(i) {
+				missing = append(missing, name)
+			}
+		}
+		return 0, incompleteError("field set in the hub", best.Version, missing)
+	}
+	return best.Version, nil
+}
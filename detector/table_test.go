@@ -0,0 +1,66 @@
+package detector
+
+import (
+	"github.com/aws/smithy-go/ptr"
+	"testing"
+)
+
+// mockEntityFieldTable is the table the generator writes for MockEntity
+var mockEntityFieldTable = FieldTable{
+	Fields: []string{"InEveryVersion", "OnlyIn1", "From2ToEnd"},
+	Eras: []EraFields{
+		{Version: Version1, Fields: Bitset{0x3}},
+		{Version: Version2, Fields: Bitset{0x5}},
+	},
+}
+
+func TestBitset(t *testing.T) {
+	set := NewBitset(130)
+	if len(set) != 3 {
+		t.Fatalf("Expected 3 words for 130 fields, got %d", len(set))
+	}
+
+	set.Add(0)
+	set.Add(64)
+	set.Add(129)
+	for _, field := range []int{0, 64, 129} {
+		if !set.Has(field) {
+			t.Errorf("Expected field %d in the set", field)
+		}
+	}
+	for _, field := range []int{1, 63, 128, 200} {
+		if set.Has(field) {
+			t.Errorf("Expected field %d not to be in the set", field)
+		}
+	}
+}
+
+func TestFieldTable_Detect(t *testing.T) {
+	// Every combination of set fields is detected like the reflective Detect does
+	for mask := 0; mask < 8; mask++ {
+		entity := MockEntity{}
+		set := NewBitset(3)
+		if mask&1 != 0 {
+			entity.InEveryVersion = ptr.String("hey")
+			set.Add(0)
+		}
+		if mask&2 != 0 {
+			entity.OnlyIn1 = ptr.Int(1)
+			set.Add(1)
+		}
+		if mask&4 != 0 {
+			entity.From2ToEnd = ptr.Uint8(2)
+			set.Add(2)
+		}
+
+		want, wantErr := Detect[MockEntity](entity)
+		got, err := mockEntityFieldTable.Detect(set)
+		if got != want || (err == nil) != (wantErr == nil) || (err != nil && err.Error() != wantErr.Error()) {
+			t.Errorf("Detect() with fields %03b = %v, %v, want %v, %v", mask, got, err, want, wantErr)
+		}
+	}
+
+	if _, err := (FieldTable{}).Detect(NewBitset(0)); err == nil {
+		t.Error("Expected error for a table without eras, got none")
+	}
+}
@@ -0,0 +1,74 @@
+package envelope
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gerardforcada/structera/interfaces"
+	"reflect"
+)
+
+// Envelope is the shape eras are stored and sent in, telling the type and
+// version of the era next to its content:
+//
+//	{"type":"user","version":3,"data":{...}}
+type Envelope struct {
+	Type    string          `json:"type"`
+	Version int             `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Marshal wraps the era in an envelope, typed after its GetName and GetVersion.
+func Marshal(era interfaces.Era) ([]byte, error) {
+	if value := reflect.ValueOf(era); !value.IsValid() || (value.Kind() == reflect.Ptr && value.IsNil()) {
+		return nil, fmt.Errorf("no era to marshal")
+	}
+
+	data, err := json.Marshal(era)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling era: %w", err)
+	}
+
+	return json.Marshal(Envelope{
+		Type:    era.GetName(),
+		Version: era.GetVersion(),
+		Data:    data,
+	})
+}
+
+// Unmarshal decodes an envelope into the era of its type and version, and
+// fills the hub of the type with it. The hub comes from the GetHubFromType
+// function of the generated types.go, e.g.
+//
+//	era, hub, err := envelope.Unmarshal(data, version.GetHubFromType)
+func Unmarshal[T ~string](data []byte, getHubFromType func(T) (interfaces.Hub, error)) (interfaces.Era, interfaces.Hub, error) {
+	var document Envelope
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, nil, fmt.Errorf("error unmarshaling envelope: %w", err)
+	}
+	if document.Type == "" {
+		return nil, nil, fmt.Errorf("envelope has no type")
+	}
+
+	hub, err := getHubFromType(T(document.Type))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The era the hub returns for the version only gives its concrete type
+	eraOfVersion, err := hub.GetEraFromVersion(document.Version)
+	if err != nil {
+		return nil, nil, fmt.Errorf("type %s: %w", document.Type, err)
+	}
+	target := reflect.New(reflect.TypeOf(eraOfVersion))
+	if len(document.Data) > 0 {
+		if err := json.Unmarshal(document.Data, target.Interface()); err != nil {
+			return nil, nil, fmt.Errorf("error unmarshaling %s version %d: %w", document.Type, document.Version, err)
+		}
+	}
+	era := target.Elem().Interface().(interfaces.Era)
+
+	if err := hub.FromEra(era); err != nil {
+		return nil, nil, err
+	}
+	return era, hub, nil
+}
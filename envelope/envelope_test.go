@@ -0,0 +1,68 @@
+package envelope
+
+import (
+	"github.com/gerardforcada/structera/example/version"
+	"github.com/gerardforcada/structera/example/version/order"
+	"github.com/gerardforcada/structera/example/version/user"
+	"github.com/gerardforcada/structera/interfaces"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMarshal(t *testing.T) {
+	data, err := Marshal(user.V2{InEveryVersion: "hey", From2ToEnd: 2})
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `{"type":"user","version":2,"data":{"in_every_version":"hey","from_2_to_end":2,`)
+
+	// Eras keep their own encoding
+	data, err = Marshal(&order.V3{ID: "a"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":"order","version":3,"data":{"schema_version":3,"id":"a","total_cents":0,"currency":"","created_by":""}}`, string(data))
+
+	var nilEra *user.V1
+	_, err = Marshal(nilEra)
+	assert.Error(t, err)
+	_, err = Marshal(nil)
+	assert.Error(t, err)
+}
+
+func TestUnmarshal(t *testing.T) {
+	data, err := Marshal(user.V3{InEveryVersion: "hey", From2ToEnd: 3, AndCustomTypes: "active"})
+	assert.NoError(t, err)
+
+	era, hub, err := Unmarshal(data, version.GetHubFromType)
+	assert.NoError(t, err)
+	assert.Equal(t, user.V3{InEveryVersion: "hey", From2ToEnd: 3, AndCustomTypes: "active"}, era)
+
+	// The hub holds the era, ready to be converted to another one
+	userHub, ok := hub.(*version.User)
+	assert.True(t, ok)
+	assert.Equal(t, "hey", *userHub.InEveryVersion)
+	var v5 user.V5
+	assert.NoError(t, hub.ToEra(&v5))
+	assert.Equal(t, uint8(3), v5.From2ToEnd)
+
+	era, _, err = Unmarshal([]byte(`{"type":"order","version":1,"data":{"id":"a","total":5}}`), version.GetHubFromType)
+	assert.NoError(t, err)
+	assert.Equal(t, interfaces.Era(order.V1{SchemaVersion: 0, ID: "a", Total: 5}), era)
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{name: "No type", data: `{"version":1,"data":{}}`, wantErr: "envelope has no type"},
+		{name: "Unknown type", data: `{"type":"admin","version":1,"data":{}}`, wantErr: "unknown type admin"},
+		{name: "Unknown version", data: `{"type":"user","version":9,"data":{}}`, wantErr: "type user: unknown version 9"},
+		{name: "Invalid data", data: `{"type":"user","version":1,"data":{"only_in_1":"one"}}`, wantErr: "error unmarshaling user version 1: "},
+		{name: "Invalid envelope", data: `[]`, wantErr: "error unmarshaling envelope: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Unmarshal([]byte(tt.data), version.GetHubFromType)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
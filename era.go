@@ -2,23 +2,50 @@ package main
 
 import (
 	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 type VersionedEraTemplateData struct {
-	ExistingImports []string
-	StructName      StructName
-	Fields          []HubFieldInfo
-	VersionNumber   int
+	Imports       []Import
+	StructName    StructName
+	Fields        []HubFieldInfo
+	VersionNumber int
+	Discriminator *HubFieldInfo
 }
 
-func (g *Generator) EraFile(existingImports []string, version int, fields []HubFieldInfo) error {
-	versionedDir := filepath.Join(g.OutputDir, g.Package, g.StructName.Snake)
-	if err := os.MkdirAll(versionedDir, os.ModePerm); err != nil {
-		return err
-	}
-	if _, err := os.Stat(filepath.Join(versionedDir, fmt.Sprintf("v%d.go", version))); err == nil {
+// MigrateTemplateData holds the migrations between adjacent eras. They live
+// apart from the eras, so adding a version doesn't need the existing eras
+// regenerated.
+type MigrateTemplateData struct {
+	StructName StructName
+	Eras       []EraMigrations
+}
+
+type EraMigrations struct {
+	Version   int
+	Upgrade   *EraMigration
+	Downgrade *EraMigration
+}
+
+// EraMigration moves an era to an adjacent one, copying the fields both share.
+type EraMigration struct {
+	Version int
+	Fields  []HubFieldInfo
+}
+
+func (g *Generator) eraPath(version int) string {
+	return filepath.Join(g.OutputDir, g.Package, g.StructName.Snake, fmt.Sprintf("v%d.go", version))
+}
+
+func (g *Generator) EraFile(imports []Import, version int, fields []HubFieldInfo) error {
+	eraPath := g.eraPath(version)
+	if _, err := os.Stat(eraPath); err == nil && !g.Check {
 		if !g.Replace {
 			fmt.Printf("Skipping existing versioned %s struct file: v%d.go\n", g.StructName.Original, version)
 			return nil
@@ -26,14 +53,145 @@ func (g *Generator) EraFile(existingImports []string, version int, fields []HubF
 		fmt.Printf("Replacing existing versioned %s struct file: v%d.go\n", g.StructName.Original, version)
 	}
 
+	// The discriminator is written by a MarshalJSON method of the era
+	var templateImports []Import
+	if discriminator(fields) != nil {
+		templateImports = []Import{{Path: "encoding/json"}}
+	}
+
 	return g.FileFromTemplate(GenerateFileFromTemplateInput{
 		TemplateFilePath: "era.go.tmpl",
-		OutputFilePath:   filepath.Join(versionedDir, fmt.Sprintf("v%d.go", version)),
+		OutputFilePath:   eraPath,
+		Source:           g.Source,
+		Era:              true,
 		Data: VersionedEraTemplateData{
-			ExistingImports: existingImports,
-			StructName:      g.StructName,
-			Fields:          fields,
-			VersionNumber:   version,
+			Imports:       append(templateImports, g.importsFor(imports, fields, templateImports)...),
+			StructName:    g.StructName,
+			Fields:        fields,
+			VersionNumber: version,
+			Discriminator: discriminator(fields),
 		},
 	})
 }
+
+// MigrateFile generates the Upgrade and Downgrade methods of every era next
+// to them, in a file regenerated on every run unlike the eras.
+func (g *Generator) MigrateFile() error {
+	var eras []EraMigrations
+	for _, version := range g.Format.SortedVersions {
+		fields := g.VersionedFields[version]
+		eras = append(eras, EraMigrations{
+			Version:   version,
+			Upgrade:   g.eraMigration(fields, version, 1),
+			Downgrade: g.eraMigration(fields, version, -1),
+		})
+	}
+
+	return g.FileFromTemplate(GenerateFileFromTemplateInput{
+		TemplateFilePath: "migrate.go.tmpl",
+		OutputFilePath:   filepath.Join(g.OutputDir, g.Package, g.StructName.Snake, "migrate.go"),
+		Source:           g.Source,
+		Data: MigrateTemplateData{
+			StructName: g.StructName,
+			Eras:       eras,
+		},
+	})
+}
+
+// eraMigration returns the migration to the next era (step 1) or the previous
+// one (step -1), if there is one.
+func (g *Generator) eraMigration(fields []HubFieldInfo, version, step int) *EraMigration {
+	versions := g.Format.SortedVersions
+	for i := range versions {
+		if versions[i] != version || i+step < 0 || i+step >= len(versions) {
+			continue
+		}
+
+		migration := &EraMigration{Version: versions[i+step]}
+		for _, field := range g.VersionedFields[migration.Version] {
+			if _, ok := findField(fields, field.Name); ok {
+				migration.Fields = append(migration.Fields, field)
+			}
+		}
+		return migration
+	}
+	return nil
+}
+
+// ValidateSkippedEras fails when an existing era, which is kept as it is without
+// --force, no longer has the fields the struct gives its version. The hub and
+// the migrations are generated from the struct, so they would refer to fields
+// the era doesn't have.
+func (g *Generator) ValidateSkippedEras() error {
+	if g.Replace || g.Check {
+		return nil
+	}
+
+	for _, version := range g.Format.SortedVersions {
+		existing, err := eraFields(g.eraPath(version), fmt.Sprintf("V%d", version))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		var fields []string
+		for _, field := range g.VersionedFields[version] {
+			fields = append(fields, eraField(field.Name, field.Type))
+		}
+		if strings.Join(existing, "\n") != strings.Join(fields, "\n") {
+			return fmt.Errorf("era V%d is out of date with the struct, regenerate it with --force", version)
+		}
+	}
+	return nil
+}
+
+// eraFields returns the fields of the era struct declared in the file, with
+// their types as written.
+func eraFields(path, name string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	node, err := parser.ParseFile(token.NewFileSet(), path, content, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	format := &Format{}
+	for _, decl := range node.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok || typeSpec.Name.Name != name {
+				continue
+			}
+			structType, ok := typeSpec.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+
+			var fields []string
+			for _, field := range structType.Fields.List {
+				for _, fieldName := range format.FieldNames(field) {
+					fields = append(fields, eraField(fieldName, types.ExprString(field.Type)))
+				}
+			}
+			return fields, nil
+		}
+	}
+	return nil, fmt.Errorf("%s doesn't declare the era %s", path, name)
+}
+
+// eraField renders the field the same way whether it comes from the struct or
+// from an era on disk.
+func eraField(name, fieldType string) string {
+	if expr, err := parser.ParseExpr(fieldType); err == nil {
+		fieldType = types.ExprString(expr)
+	}
+	return name + " " + fieldType
+}
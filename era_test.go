@@ -2,9 +2,13 @@ package main
 
 import (
 	"fmt"
+	"github.com/gerardforcada/structera/example"
+	"github.com/gerardforcada/structera/example/version"
+	"github.com/gerardforcada/structera/example/version/user"
 	"github.com/stretchr/testify/assert"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -19,15 +23,15 @@ func TestGenerator_EraFile(t *testing.T) {
 	}()
 
 	type fields struct {
-		ExistingImports []string
-		StructName      StructName
-		Fields          []HubFieldInfo
-		VersionNumber   int
+		Imports       []Import
+		StructName    StructName
+		Fields        []HubFieldInfo
+		VersionNumber int
 	}
 	tests := []struct {
 		name            string
 		fields          fields
-		existingImports []string
+		existingImports []Import
 		importPath      string
 		wantErr         bool
 		wantMatch       bool
@@ -67,7 +71,7 @@ func TestGenerator_EraFile(t *testing.T) {
 					},
 				},
 			},
-			existingImports: []string{},
+			existingImports: []Import{},
 			importPath:      "github.com/gerardforcada/structera/example",
 			wantErr:         false,
 			wantMatch:       true,
@@ -77,16 +81,32 @@ func TestGenerator_EraFile(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			g := &Generator{
-				StructName:      tt.fields.StructName,
-				OutputDir:       tempDir,
-				Format:          &Format{},
-				VersionedFields: map[int][]HubFieldInfo{},
+				StructName: tt.fields.StructName,
+				OutputDir:  tempDir,
+				Format:     &Format{SortedVersions: []int{1, 2}},
+				VersionedFields: map[int][]HubFieldInfo{
+					1: tt.fields.Fields,
+					2: {
+						{Name: "InEveryVersion", Type: "string"},
+						{Name: "From2ToEnd", Type: "uint8"},
+						{Name: "FromStartTo3", Type: "[]byte"},
+						{Name: "From1to4", Type: "float32"},
+					},
+				},
+				Filename: "example/testing.go",
+				Resolver: &Resolver{},
 			}
+			fileSet, _, structType, err := g.ParseStruct()
+			assert.NoError(t, err)
+			assert.NoError(t, g.fingerprint(fileSet, structType))
 
 			if err := g.EraFile(tt.existingImports, 1, tt.fields.Fields); (err != nil) != tt.wantErr {
 				t.Errorf("HubFile() error = %v, wantErr %v", err, tt.wantErr)
 			}
 
+			// Only the rendering is tested here, so the file is written without type-checking its package
+			assert.NoError(t, g.Output.Write())
+
 			// Path to the generated file
 			generatedFilePath := filepath.Join(tempDir, tt.fields.StructName.Lower, fmt.Sprintf("v%d.go", 1))
 
@@ -110,3 +130,139 @@ func TestGenerator_EraFile(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerator_VersionedStructs_NewVersion(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	fileName := filepath.Join(tempDir, "user.go")
+	source := "package models\n\ntype User struct {\n\tName  string\n\tEmail string `version:\"2+\"`\n}\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte(goMod(t, "example.com/eras")), 0644))
+	assert.NoError(t, os.WriteFile(fileName, []byte(source), 0644))
+	assert.NoError(t, NewGenerator(fileName, "User", "", string(ModuleFolder), false).VersionedStructs())
+
+	eraDir := filepath.Join(tempDir, string(ModuleFolder), "user")
+	v1, err := os.ReadFile(filepath.Join(eraDir, "v1.go"))
+	assert.NoError(t, err)
+	v2, err := os.ReadFile(filepath.Join(eraDir, "v2.go"))
+	assert.NoError(t, err)
+
+	// Adding a version only needs the migrations regenerated, not the existing eras
+	source = "package models\n\ntype User struct {\n\tName  string\n\tEmail string `version:\"2+\"`\n\tPhone string `version:\"3+\"`\n}\n"
+	assert.NoError(t, os.WriteFile(fileName, []byte(source), 0644))
+	assert.NoError(t, NewGenerator(fileName, "User", "", string(ModuleFolder), false).VersionedStructs())
+
+	v1After, err := os.ReadFile(filepath.Join(eraDir, "v1.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, string(v1), string(v1After))
+	v2After, err := os.ReadFile(filepath.Join(eraDir, "v2.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, string(v2), string(v2After))
+	assert.FileExists(t, filepath.Join(eraDir, "v3.go"))
+
+	migrate, err := os.ReadFile(filepath.Join(eraDir, "migrate.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(migrate), "func (era V2) Upgrade(hooks ...UpgradeV2Hook) (V3, error) {")
+	assert.Contains(t, string(migrate), "func (era V3) Downgrade(hooks ...DowngradeV3Hook) (V2, error) {")
+}
+
+// TestExample_Migrate runs the migration chain generated for example/user.go
+func TestExample_Migrate(t *testing.T) {
+	hooks := version.UserHooks{
+		UpgradeV2: func(from user.V2, to *user.V3) error {
+			to.AndCustomTypes = example.Status(fmt.Sprintf("level %d", from.From2ToEnd))
+			return nil
+		},
+	}
+
+	hub := version.User{}
+	era, err := hub.Migrate(user.V1{InEveryVersion: "hey", OnlyIn1: 1, From1to4: 1.5}, 4, hooks)
+	assert.NoError(t, err)
+	assert.Equal(t, user.V4{InEveryVersion: "hey", From1to4: 1.5, AndCustomTypes: "level 0"}, era)
+
+	// Without hooks, only the shared fields are copied
+	era, err = hub.Migrate(user.V1{InEveryVersion: "hey", From1to4: 1.5}, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, user.V4{InEveryVersion: "hey", From1to4: 1.5}, era)
+
+	// Fields the lower eras don't have are lost on the way down
+	v4 := user.V4{InEveryVersion: "hey", From1to4: 1.5, AndCustomTypes: "level 0"}
+	era, err = hub.Migrate(&v4, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, user.V2{InEveryVersion: "hey", From1to4: 1.5}, era)
+
+	// Every set of hooks is called, in order
+	var calls []string
+	record := func(name string) version.UserHooks {
+		return version.UserHooks{DowngradeV2: func(from user.V2, to *user.V1) error {
+			calls = append(calls, name)
+			return nil
+		}}
+	}
+	_, err = hub.Migrate(era, 1, record("first"), record("second"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, calls)
+
+	failing := version.UserHooks{DowngradeV2: func(from user.V2, to *user.V1) error {
+		return fmt.Errorf("V1 needs OnlyIn1")
+	}}
+	_, err = hub.Migrate(era, 1, failing)
+	assert.EqualError(t, err, "error migrating from version 2: V1 needs OnlyIn1")
+
+	// The eras migrate on their own too
+	v2, err := user.V1{InEveryVersion: "hey", OnlyIn1: 7}.Upgrade(func(from user.V1, to *user.V2) error {
+		to.From2ToEnd = uint8(from.OnlyIn1)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(7), v2.From2ToEnd)
+
+	_, err = hub.Migrate(era, 6)
+	assert.EqualError(t, err, "unknown version 6")
+}
+
+func TestGenerator_ValidateSkippedEras(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	writeBatchFixture(t, tempDir)
+	fileName := filepath.Join(tempDir, "models", "user.go")
+	hubPath := filepath.Join(tempDir, "models", string(ModuleFolder), "user.go")
+	assert.NoError(t, NewGenerator(fileName, "User", "", string(ModuleFolder), false).VersionedStructs())
+	editSource := func(old, new string) {
+		source, err := os.ReadFile(fileName)
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(fileName, []byte(strings.Replace(string(source), old, new, 1)), 0644))
+	}
+
+	// A new version leaves the existing eras as they are
+	editSource("\tName  string\n", "\tName  string\n\tPhone string `version:\"4\"`\n")
+	assert.NoError(t, NewGenerator(fileName, "User", "", string(ModuleFolder), false).VersionedStructs())
+
+	// A field added to existing versions fails before rendering anything, and nothing is written
+	hubBefore, err := os.ReadFile(hubPath)
+	assert.NoError(t, err)
+	editSource("\tName  string\n", "\tName  string\n\tWhen  int64\n")
+	err = NewGenerator(fileName, "User", "", string(ModuleFolder), false).VersionedStructs()
+	assert.EqualError(t, err, "era V1 is out of date with the struct, regenerate it with --force")
+	hubAfter, err := os.ReadFile(hubPath)
+	assert.NoError(t, err)
+	assert.Equal(t, string(hubBefore), string(hubAfter))
+
+	// So does a changed type
+	editSource("\tWhen  int64\n", "")
+	editSource("\tEmail string", "\tEmail []byte")
+	err = NewGenerator(fileName, "User", "", string(ModuleFolder), false).VersionedStructs()
+	assert.EqualError(t, err, "era V2 is out of date with the struct, regenerate it with --force")
+
+	assert.NoError(t, NewGenerator(fileName, "User", "", string(ModuleFolder), true).VersionedStructs())
+	assert.NoError(t, NewGenerator(fileName, "User", "", string(ModuleFolder), false).VersionedStructs())
+}
@@ -0,0 +1,22 @@
+package example
+
+// Audit Fields shared by several models, promoted to the JSON of the structs embedding them
+type Audit struct {
+	CreatedBy string `json:"created_by"`
+}
+
+// Shipping Embedded by pointer, so eras without an address leave it out of the JSON
+type Shipping struct {
+	Address string `json:"address"`
+}
+
+// Order Struct whose payloads carry their version
+type Order struct {
+	SchemaVersion int    `json:"schema_version" version:"discriminator"`
+	ID            string `json:"id"`
+	Total         int64  `json:"total" version:"1"`
+	TotalCents    int64  `json:"total_cents" version:"2+"`
+	Currency      string `json:"currency" version:"3"`
+	Audit
+	*Shipping `version:"2+"`
+}
@@ -1,5 +1,8 @@
 package example
 
+// Status Custom type declared next to the versioned struct
+type Status string
+
 // User Original struct with version tags
 type User struct {
 	InEveryVersion string  `json:"in_every_version"`
@@ -12,8 +15,12 @@ type User struct {
 	WorksWithMaps     map[string]int64
 	AndMapsInMaps     map[string]map[string]int64
 	AndSlices         []int
+	AndArrays         [16]byte
+	AndStructs        struct{ Value string }
 	AndPointers       *int
 	AndDoublePointers **int
 	AndGenerics       any
 	AndOldGenerics    interface{}
+	AndCustomTypes    Status `version:"3+"`
+	AndSkippedInJSON  string `json:"-"`
 }
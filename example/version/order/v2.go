@@ -0,0 +1,34 @@
+// Code generated by structera; DO NOT EDIT.
+// Source: example/order.go:Order sha256:1f4f4fb8bd0b1e2abd1665f911149bb4799abe4ddb0684c4ffd216fa167af4b5
+// Checksum: sha256:b42650830d1e075d7c4e513d72d2b87fee166f59816add96c73e39759a1b21f7
+
+package order
+
+import (
+	"encoding/json"
+	originalPackage "github.com/gerardforcada/structera/example"
+)
+
+// V2 Version-specific struct types and methods
+type V2 struct {
+	SchemaVersion int    `json:"schema_version"`
+	ID            string `json:"id"`
+	TotalCents    int64  `json:"total_cents"`
+	originalPackage.Audit
+	*originalPackage.Shipping
+}
+
+func (era V2) GetVersion() int {
+	return 2
+}
+
+func (era V2) GetName() string {
+	return "order"
+}
+
+// MarshalJSON writes the version of the era into SchemaVersion
+func (era V2) MarshalJSON() ([]byte, error) {
+	type plain V2
+	era.SchemaVersion = 2
+	return json.Marshal(plain(era))
+}
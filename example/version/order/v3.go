@@ -0,0 +1,35 @@
+// Code generated by structera; DO NOT EDIT.
+// Source: example/order.go:Order sha256:1f4f4fb8bd0b1e2abd1665f911149bb4799abe4ddb0684c4ffd216fa167af4b5
+// Checksum: sha256:ec428040eb8d8723c9750751cd1c3650d583ac404cdd4c85db346acd7f2ad5f9
+
+package order
+
+import (
+	"encoding/json"
+	originalPackage "github.com/gerardforcada/structera/example"
+)
+
+// V3 Version-specific struct types and methods
+type V3 struct {
+	SchemaVersion int    `json:"schema_version"`
+	ID            string `json:"id"`
+	TotalCents    int64  `json:"total_cents"`
+	Currency      string `json:"currency"`
+	originalPackage.Audit
+	*originalPackage.Shipping
+}
+
+func (era V3) GetVersion() int {
+	return 3
+}
+
+func (era V3) GetName() string {
+	return "order"
+}
+
+// MarshalJSON writes the version of the era into SchemaVersion
+func (era V3) MarshalJSON() ([]byte, error) {
+	type plain V3
+	era.SchemaVersion = 3
+	return json.Marshal(plain(era))
+}
@@ -1,24 +1,31 @@
+// Code generated by structera; DO NOT EDIT.
+// Source: example/user.go:User sha256:38a51083d0b6204a2ccb0ca6a995e37bf078dba18fb90f3be0c9066da8cf1f88
+// Checksum: sha256:8e35aefab8232f932328a64a1feeb791384938c159b1f9320f331cb3328b36df
+
 package user
 
 // V2 Version-specific struct types and methods
 type V2 struct {
-    InEveryVersion    string `json:"in_every_version"`
-    From2ToEnd        uint8 `json:"from_2_to_end"`
-    FromStartTo3      []byte `json:"from_start_to_3"`
-    From1to4          float32 `json:"from_1_to_4"`
-    WorksWithMaps     map[string]int64
-    AndMapsInMaps     map[string]map[string]int64
-    AndSlices         []int
-    AndPointers       *int
-    AndDoublePointers **int
-    AndGenerics       any
-    AndOldGenerics    any
+	InEveryVersion    string  `json:"in_every_version"`
+	From2ToEnd        uint8   `json:"from_2_to_end"`
+	FromStartTo3      []byte  `json:"from_start_to_3"`
+	From1to4          float32 `json:"from_1_to_4"`
+	WorksWithMaps     map[string]int64
+	AndMapsInMaps     map[string]map[string]int64
+	AndSlices         []int
+	AndArrays         [16]byte
+	AndStructs        struct{ Value string }
+	AndPointers       *int
+	AndDoublePointers **int
+	AndGenerics       any
+	AndOldGenerics    interface{}
+	AndSkippedInJSON  string `json:"-"`
 }
 
 func (era V2) GetVersion() int {
-    return 2
+	return 2
 }
 
 func (era V2) GetName() string {
-    return "user"
+	return "user"
 }
@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"go/ast"
+	"go/token"
+	"go/types"
 	"reflect"
 	"sort"
 	"strconv"
@@ -10,15 +12,22 @@ import (
 )
 
 const (
-	VersionTag = "version"
+	VersionTag         = "version"
+	DiscriminatorTag   = "discriminator" // version:"discriminator" marks the field holding the version of the payload
+	SourcePackageAlias = "originalPackage"
 )
 
 type Format struct {
 	Versions       map[int][]string
 	SortedVersions []int
 	CustomType     bool
+	SourceAlias    string
+	Renames        map[string]string
+	qualifiers     map[string]bool
 }
 
+// FieldType renders any Go type expression, qualifying the types declared in
+// the source package, and adds a pointer on top when requested for the hub.
 func (f *Format) FieldType(expr ast.Expr, pointer bool) string {
 	result := ""
 	if pointer {
@@ -30,14 +39,18 @@ func (f *Format) FieldType(expr ast.Expr, pointer bool) string {
 		// Check if it's a custom type (non-builtin)
 		if isCustomType(t.Name) {
 			f.CustomType = true
-			result += "originalPackage." + t.Name
+			f.qualify(f.sourceAlias())
+			result += f.sourceAlias() + "." + t.Name
 		} else {
 			result += t.Name
 		}
 	case *ast.ArrayType:
-		// For arrays and slices
-		elementType := f.FieldType(t.Elt, false)
-		result += fmt.Sprintf("[]%s", elementType)
+		// For slices, fixed-size arrays ([16]byte) and [...]T
+		length := ""
+		if t.Len != nil {
+			length = f.FieldType(t.Len, false)
+		}
+		result += fmt.Sprintf("[%s]%s", length, f.FieldType(t.Elt, false))
 	case *ast.MapType:
 		// For maps
 		keyType := f.FieldType(t.Key, false)
@@ -48,16 +61,162 @@ func (f *Format) FieldType(expr ast.Expr, pointer bool) string {
 		pointedType := f.FieldType(t.X, true)
 		result += pointedType
 	case *ast.SelectorExpr:
-		// For qualified identifiers (e.g., time.Time)
-		result += fmt.Sprintf("%s.%s", t.X, t.Sel.Name)
+		// For qualified identifiers (e.g., time.Time), the package name is never qualified again
+		qualifier := types.ExprString(t.X)
+		if renamed, ok := f.Renames[qualifier]; ok {
+			qualifier = renamed
+		}
+		f.qualify(qualifier)
+		result += fmt.Sprintf("%s.%s", qualifier, t.Sel.Name)
+	case *ast.ChanType:
+		result += f.chanType(t)
+	case *ast.FuncType:
+		result += "func" + f.signature(t)
+	case *ast.StructType:
+		result += f.structType(t)
+	case *ast.InterfaceType:
+		result += f.interfaceType(t)
+	case *ast.IndexExpr:
+		// For generic instantiations (e.g., Optional[int])
+		result += fmt.Sprintf("%s[%s]", f.FieldType(t.X, false), f.FieldType(t.Index, false))
+	case *ast.IndexListExpr:
+		// For generic instantiations with several type arguments (e.g., Pair[K, V])
+		result += fmt.Sprintf("%s[%s]", f.FieldType(t.X, false), f.typeList(t.Indices))
+	case *ast.ParenExpr:
+		result += fmt.Sprintf("(%s)", f.FieldType(t.X, false))
+	case *ast.Ellipsis:
+		// For variadic parameters
+		result += "..." + f.FieldType(t.Elt, false)
+	case *ast.UnaryExpr:
+		// For approximation elements (~int) and array length expressions
+		result += t.Op.String() + f.FieldType(t.X, false)
+	case *ast.BinaryExpr:
+		// For unions (int | string) and array length expressions
+		result += fmt.Sprintf("%s %s %s", f.FieldType(t.X, false), t.Op, f.FieldType(t.Y, false))
+	case *ast.BasicLit:
+		// For array lengths
+		result += t.Value
 	default:
-		// Fallback for other types
-		result += "any" // Generic pointer type as fallback
+		// Fallback for other expressions, rendered as they are
+		result += types.ExprString(expr)
 	}
 
 	return result
 }
 
+// FieldQualifiers returns the package names the rendered type refers to.
+func (f *Format) FieldQualifiers(expr ast.Expr) []string {
+	f.qualifiers = map[string]bool{}
+	defer func() { f.qualifiers = nil }()
+	f.FieldType(expr, false)
+
+	var qualifiers []string
+	for qualifier := range f.qualifiers {
+		qualifiers = append(qualifiers, qualifier)
+	}
+	sort.Strings(qualifiers)
+	return qualifiers
+}
+
+func (f *Format) qualify(name string) {
+	if f.qualifiers != nil {
+		f.qualifiers[name] = true
+	}
+}
+
+func (f *Format) sourceAlias() string {
+	if f.SourceAlias == "" {
+		return SourcePackageAlias
+	}
+	return f.SourceAlias
+}
+
+func (f *Format) chanType(t *ast.ChanType) string {
+	valueType := f.FieldType(t.Value, false)
+	switch t.Dir {
+	case ast.SEND:
+		return "chan<- " + valueType
+	case ast.RECV:
+		return "<-chan " + valueType
+	}
+
+	// chan (<-chan T) needs the parentheses, otherwise it reads as chan<- (chan T)
+	if value, ok := t.Value.(*ast.ChanType); ok && value.Dir == ast.RECV {
+		return fmt.Sprintf("chan (%s)", valueType)
+	}
+	return "chan " + valueType
+}
+
+// signature renders the parameters and results of a function type.
+func (f *Format) signature(t *ast.FuncType) string {
+	result := fmt.Sprintf("(%s)", f.fieldList(t.Params, ", "))
+	if t.Results == nil || len(t.Results.List) == 0 {
+		return result
+	}
+
+	results := f.fieldList(t.Results, ", ")
+	if len(t.Results.List) == 1 && len(t.Results.List[0].Names) == 0 {
+		return result + " " + results
+	}
+	return fmt.Sprintf("%s (%s)", result, results)
+}
+
+func (f *Format) structType(t *ast.StructType) string {
+	if t.Fields == nil || len(t.Fields.List) == 0 {
+		return "struct{}"
+	}
+	return fmt.Sprintf("struct{ %s }", f.fieldList(t.Fields, "; "))
+}
+
+func (f *Format) interfaceType(t *ast.InterfaceType) string {
+	if t.Methods == nil || len(t.Methods.List) == 0 {
+		return "interface{}"
+	}
+
+	var elements []string
+	for _, method := range t.Methods.List {
+		if funcType, ok := method.Type.(*ast.FuncType); ok && len(method.Names) > 0 {
+			elements = append(elements, method.Names[0].Name+f.signature(funcType))
+			continue
+		}
+		elements = append(elements, f.FieldType(method.Type, false))
+	}
+	return fmt.Sprintf("interface{ %s }", strings.Join(elements, "; "))
+}
+
+// fieldList renders parameters, results or struct fields, keeping names and tags.
+func (f *Format) fieldList(fields *ast.FieldList, separator string) string {
+	if fields == nil {
+		return ""
+	}
+
+	var rendered []string
+	for _, field := range fields.List {
+		var names []string
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+
+		item := f.FieldType(field.Type, false)
+		if len(names) > 0 {
+			item = strings.Join(names, ", ") + " " + item
+		}
+		if field.Tag != nil {
+			item += " " + field.Tag.Value
+		}
+		rendered = append(rendered, item)
+	}
+	return strings.Join(rendered, separator)
+}
+
+func (f *Format) typeList(exprs []ast.Expr) string {
+	var rendered []string
+	for _, expr := range exprs {
+		rendered = append(rendered, f.FieldType(expr, false))
+	}
+	return strings.Join(rendered, ", ")
+}
+
 func isCustomType(typeName string) bool {
 	builtInTypes := map[string]bool{
 		"bool": true, "int": true, "int8": true, "int16": true, "int32": true, "int64": true,
@@ -74,6 +233,34 @@ func isCustomType(typeName string) bool {
 	return !isBuiltIn
 }
 
+// UnexportedIdent returns the first unexported identifier the type refers to
+// in the source package, like status in []status, if any. Predeclared
+// identifiers, the names of packages and the names of fields, parameters and
+// methods of type literals don't count.
+func (f *Format) UnexportedIdent(expr ast.Expr) *ast.Ident {
+	var unexported *ast.Ident
+	var inspect func(ast.Node) bool
+	inspect = func(node ast.Node) bool {
+		if unexported != nil {
+			return false
+		}
+		switch n := node.(type) {
+		case *ast.SelectorExpr:
+			return false
+		case *ast.Field:
+			ast.Inspect(n.Type, inspect)
+			return false
+		case *ast.Ident:
+			if !n.IsExported() && types.Universe.Lookup(n.Name) == nil {
+				unexported = n
+			}
+		}
+		return true
+	}
+	ast.Inspect(expr, inspect)
+	return unexported
+}
+
 func (f *Format) IdentifyVersions(structType *ast.StructType) {
 	var allTags []string
 	// Collect all version tags from the struct fields
@@ -98,9 +285,8 @@ func (f *Format) IdentifyVersions(structType *ast.StructType) {
 			}
 		}
 
-		fieldType := fmt.Sprintf("%s", field.Type)
-		fieldType = f.FieldType(field.Type, false)
-		for _, name := range field.Names {
+		fieldType := f.FieldType(field.Type, false)
+		for _, name := range f.FieldNames(field) {
 			fieldStr := fmt.Sprintf("%s %s", name, fieldType)
 			for _, v := range versions {
 				versionMap[v] = append(versionMap[v], fieldStr)
@@ -116,9 +302,58 @@ func (f *Format) IdentifyVersions(structType *ast.StructType) {
 	sort.Ints(f.SortedVersions)
 }
 
+// FieldNames returns the names declared by the field, or the implicit name
+// of an embedded field (Audit for Audit, *Audit, pkg.Audit or Audit[T]).
+func (f *Format) FieldNames(field *ast.Field) []string {
+	var names []string
+	for _, name := range field.Names {
+		names = append(names, name.Name)
+	}
+	if len(names) == 0 {
+		names = append(names, f.embeddedName(field.Type))
+	}
+	return names
+}
+
+func (f *Format) embeddedName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return f.embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.IndexExpr:
+		return f.embeddedName(t.X)
+	case *ast.IndexListExpr:
+		return f.embeddedName(t.X)
+	}
+	return ""
+}
+
+// IsDiscriminator reports whether the field is tagged version:"discriminator".
+func (f *Format) IsDiscriminator(field *ast.Field) bool {
+	if field.Tag == nil {
+		return false
+	}
+	return reflect.StructTag(field.Tag.Value[1:len(field.Tag.Value)-1]).Get(VersionTag) == DiscriminatorTag
+}
+
+func (f *Format) HasVersionTags(structType *ast.StructType) bool {
+	for _, field := range structType.Fields.List {
+		if field.Tag == nil {
+			continue
+		}
+		if _, ok := reflect.StructTag(field.Tag.Value[1 : len(field.Tag.Value)-1]).Lookup(VersionTag); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func (f *Format) ParseVersionTag(tag string, maxVersion int) []int {
-	if tag == "" {
-		// If no tag, include in all versions
+	if tag == "" || tag == DiscriminatorTag {
+		// If no tag, include in all versions, like the discriminator
 		var versions []int
 		for i := 1; i <= maxVersion; i++ {
 			versions = append(versions, i)
@@ -126,20 +361,28 @@ func (f *Format) ParseVersionTag(tag string, maxVersion int) []int {
 		return versions
 	}
 
-	// Remaining logic stays the same
-	start, end, err := f.ParseVersionRange(tag)
-	if err != nil || start > maxVersion {
-		return []int{}
-	}
+	// Each comma-separated part is a single version or a range
+	included := make(map[int]bool)
+	for _, part := range f.SplitVersionTag(tag) {
+		start, end, err := f.ParseVersionRange(part)
+		if err != nil || start > maxVersion {
+			continue
+		}
+
+		if end == -1 { // No upper limit specified
+			end = maxVersion
+		}
 
-	if end == -1 { // No upper limit specified
-		end = maxVersion
+		for v := start; v <= end; v++ {
+			included[v] = true
+		}
 	}
 
-	var versions []int
-	for v := start; v <= end; v++ {
+	versions := []int{}
+	for v := range included {
 		versions = append(versions, v)
 	}
+	sort.Ints(versions)
 
 	return versions
 }
@@ -148,23 +391,41 @@ func (f *Format) DetermineMaxVersion(versionTags []string) int {
 	maxVersion := 1
 
 	for _, tag := range versionTags {
-		_, end, err := f.ParseVersionRange(tag)
-		if err == nil && end > maxVersion {
-			maxVersion = end
+		for _, part := range f.SplitVersionTag(tag) {
+			start, end, err := f.ParseVersionRange(part)
+			if err != nil {
+				continue
+			}
+			// Open ranges like "2+" reach at least the version they start at
+			if end == -1 {
+				end = start
+			}
+			if end > maxVersion {
+				maxVersion = end
+			}
 		}
 	}
 
 	return maxVersion
 }
 
+// SplitVersionTag splits a version list like "1,3,5-7" into its parts.
+func (f *Format) SplitVersionTag(tag string) []string {
+	parts := strings.Split(tag, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts
+}
+
 func (f *Format) ParseVersionRange(tag string) (int, int, error) {
 	if tag == "" {
 		return 1, 1, nil // Default to version 1 if no tag
 	}
 
-	if strings.Contains(tag, "+") {
+	if strings.HasSuffix(tag, "+") {
 		// For "2+" style tags
-		start, err := strconv.Atoi(strings.TrimSuffix(tag, "+"))
+		start, err := parseVersionNumber(strings.TrimSuffix(tag, "+"))
 		if err != nil {
 			return 0, 0, err // Error in parsing the tag
 		}
@@ -173,18 +434,18 @@ func (f *Format) ParseVersionRange(tag string) (int, int, error) {
 
 	if strings.Contains(tag, "-") {
 		// For "1-3" or "-3" style tags
-		parts := strings.Split(tag, "-")
+		parts := strings.SplitN(tag, "-", 2)
 		start, end := 1, 0
 		var err error
 
 		if parts[0] != "" {
-			start, err = strconv.Atoi(parts[0])
+			start, err = parseVersionNumber(parts[0])
 			if err != nil {
 				return 0, 0, err // Error in parsing the tag
 			}
 		}
 
-		end, err = strconv.Atoi(parts[1])
+		end, err = parseVersionNumber(parts[1])
 		if err != nil {
 			return 0, 0, err // Error in parsing the tag
 		}
@@ -193,19 +454,208 @@ func (f *Format) ParseVersionRange(tag string) (int, int, error) {
 	}
 
 	// For single version tags like "2"
-	version, err := strconv.Atoi(tag)
+	version, err := parseVersionNumber(tag)
 	if err != nil {
 		return 0, 0, err // Error in parsing the tag
 	}
 	return version, version, nil
 }
 
+// parseVersionNumber only accepts plain digits, so signs and spaces are not
+// silently read as part of a range.
+func parseVersionNumber(value string) (int, error) {
+	if value == "" {
+		return 0, fmt.Errorf("missing version number")
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("%q is not a version number", value)
+		}
+	}
+	return strconv.Atoi(value)
+}
+
+type VersionTagError struct {
+	Position token.Position
+	Field    string
+	Tag      string
+	Message  string
+}
+
+func (e VersionTagError) Error() string {
+	message := fmt.Sprintf("field %s: invalid version tag %q: %s", e.Field, e.Tag, e.Message)
+	if e.Position.IsValid() {
+		return fmt.Sprintf("%s: %s", e.Position, message)
+	}
+	return message
+}
+
+type VersionTagErrors []VersionTagError
+
+func (e VersionTagErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// ValidateVersionTags reports every malformed version tag of the struct at
+// once, positioned at the tag value in the source file.
+func (f *Format) ValidateVersionTags(fileSet *token.FileSet, structType *ast.StructType) error {
+	var errs VersionTagErrors
+	discriminators := 0
+	for _, field := range structType.Fields.List {
+		if field.Tag == nil {
+			continue
+		}
+
+		tag, ok := reflect.StructTag(field.Tag.Value[1 : len(field.Tag.Value)-1]).Lookup(VersionTag)
+		if !ok || tag == "" {
+			continue
+		}
+
+		if tag == DiscriminatorTag {
+			discriminators++
+			message := ""
+			switch {
+			case len(field.Names) != 1:
+				message = "the discriminator must be a single named field"
+			case !isIntegerType(field.Type):
+				message = fmt.Sprintf("the discriminator must be an integer, not %s", f.FieldType(field.Type, false))
+			case discriminators > 1:
+				message = "the struct already has a discriminator"
+			}
+			if message != "" {
+				errs = append(errs, VersionTagError{
+					Position: f.tagPosition(fileSet, field.Tag),
+					Field:    f.fieldName(field),
+					Tag:      tag,
+					Message:  message,
+				})
+			}
+			continue
+		}
+
+		parts := f.SplitVersionTag(tag)
+		for _, part := range parts {
+			message := f.validateVersionRange(part)
+			if message == "" {
+				continue
+			}
+			if len(parts) > 1 {
+				message = fmt.Sprintf("%q: %s", part, message)
+			}
+
+			errs = append(errs, VersionTagError{
+				Position: f.tagPosition(fileSet, field.Tag),
+				Field:    f.fieldName(field),
+				Tag:      tag,
+				Message:  message,
+			})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// isIntegerType reports whether the field is of a predeclared integer type,
+// the ones a discriminator can hold the version in.
+func isIntegerType(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	switch ident.Name {
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return true
+	}
+	return false
+}
+
+// validateVersionRange checks a single version or range. A range can't start
+// past the highest version, as the highest version is the highest one the
+// tags name, the start of ranges included.
+func (f *Format) validateVersionRange(part string) string {
+	if part == "" {
+		return "empty entry in version list"
+	}
+
+	start, end, err := f.ParseVersionRange(part)
+	switch {
+	case err != nil:
+		return fmt.Sprintf("cannot parse range: %v", err)
+	case start <= 0 || end == 0:
+		return "versions start at 1"
+	case end != -1 && start > end:
+		return fmt.Sprintf("range is inverted, %d is greater than %d", start, end)
+	}
+
+	return ""
+}
+
+// tagPosition points at the version value inside the tag literal when the
+// tag is a raw string, and at the tag itself otherwise.
+func (f *Format) tagPosition(fileSet *token.FileSet, tag *ast.BasicLit) token.Position {
+	if fileSet == nil || !tag.Pos().IsValid() {
+		return token.Position{}
+	}
+
+	position := fileSet.Position(tag.Pos())
+	prefix := VersionTag + ":\""
+	if index := strings.Index(tag.Value, prefix); index >= 0 && strings.HasPrefix(tag.Value, "`") {
+		position.Offset += index + len(prefix)
+		position.Column += index + len(prefix)
+	}
+	return position
+}
+
+func (f *Format) fieldName(field *ast.Field) string {
+	return strings.Join(f.FieldNames(field), ", ")
+}
+
+// ExcludeVersionTag removes the version key from the struct tag, keeping the
+// other key:"value" pairs as they are written. It scans the tag the way
+// reflect.StructTag.Lookup does, so quoted values may hold spaces.
 func (f *Format) ExcludeVersionTag(tag string) string {
 	var result []string
-	tags := strings.Split(tag, " ")
-	for _, t := range tags {
-		if !strings.HasPrefix(t, fmt.Sprintf("%s:", VersionTag)) {
-			result = append(result, t)
+	for tag != "" {
+		tag = strings.TrimLeft(tag, " ")
+		if tag == "" {
+			break
+		}
+
+		// The key runs up to the colon, and the value is a quoted string right after it
+		i := 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			// Not a well-formed pair, the rest is kept as it is
+			result = append(result, tag)
+			break
+		}
+		key := tag[:i]
+
+		j := i + 2
+		for j < len(tag) && tag[j] != '"' {
+			if tag[j] == '\\' {
+				j++
+			}
+			j++
+		}
+		if j >= len(tag) {
+			result = append(result, tag)
+			break
+		}
+		pair := tag[:j+1]
+		tag = tag[j+1:]
+
+		if _, err := strconv.Unquote(pair[i+1:]); err != nil || key != VersionTag {
+			result = append(result, pair)
 		}
 	}
 	return strings.Join(result, " ")
@@ -2,6 +2,8 @@ package main
 
 import (
 	"go/ast"
+	"go/parser"
+	"go/token"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -64,13 +66,13 @@ func TestFormatFieldType(t *testing.T) {
 			name:     "Nested Struct Type",
 			expr:     &ast.StructType{},
 			pointer:  false,
-			expected: "any",
+			expected: "struct{}",
 		},
 		{
 			name:     "Pointer to Struct Type",
 			expr:     &ast.StructType{},
 			pointer:  true,
-			expected: "*any",
+			expected: "*struct{}",
 		},
 		{
 			name: "Qualified Identifier",
@@ -157,6 +159,63 @@ func TestFormatFieldType(t *testing.T) {
 	}
 }
 
+func TestFormatFieldType_Expressions(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		pointer    bool
+		expected   string
+		customType bool
+	}{
+		{"Builtin", "string", false, "string", false},
+		{"Custom type", "Status", false, "originalPackage.Status", true},
+		{"Pointer to custom type", "*Status", true, "**originalPackage.Status", true},
+		{"Qualified type", "time.Time", true, "*time.Time", false},
+		{"Slice", "[]byte", false, "[]byte", false},
+		{"Fixed-size array", "[16]byte", true, "*[16]byte", false},
+		{"Array with constant length", "[Size]Status", false, "[originalPackage.Size]originalPackage.Status", true},
+		{"Array with qualified length", "[sha256.Size]byte", false, "[sha256.Size]byte", false},
+		{"Array with length expression", "[2 * Size]byte", false, "[2 * originalPackage.Size]byte", true},
+		{"Nested arrays", "[][4][]int", false, "[][4][]int", false},
+		{"Map", "map[string][]int", false, "map[string][]int", false},
+		{"Map of qualified generic", "map[K]pkg.T[U]", false, "map[originalPackage.K]pkg.T[originalPackage.U]", true},
+		{"Channel", "chan int", false, "chan int", false},
+		{"Send channel", "chan<- int", true, "*chan<- int", false},
+		{"Receive channel", "<-chan error", false, "<-chan error", false},
+		{"Channel of receive channel", "chan (<-chan int)", false, "chan (<-chan int)", false},
+		{"Send channel of channel", "chan<- chan int", false, "chan<- chan int", false},
+		{"Func", "func()", true, "*func()", false},
+		{"Func with params and result", "func(int, string) error", false, "func(int, string) error", false},
+		{"Func with names and results", "func(ctx context.Context, ids ...Status) (n int, err error)", false, "func(ctx context.Context, ids ...originalPackage.Status) (n int, err error)", true},
+		{"Func with multiple results", "func() (int, error)", false, "func() (int, error)", false},
+		{"Func returning func", "func(func(int) bool) func() string", false, "func(func(int) bool) func() string", false},
+		{"Empty struct", "struct{}", false, "struct{}", false},
+		{"Anonymous struct", "struct{ A, B int; C Status `json:\"c\"`; time.Time }", true, "*struct{ A, B int; C originalPackage.Status `json:\"c\"`; time.Time }", true},
+		{"Empty interface", "interface{}", false, "interface{}", false},
+		{"Any", "any", true, "*any", false},
+		{"Interface with methods", "interface{ fmt.Stringer; Close() error; Read(p []byte) (n int, err error) }", false, "interface{ fmt.Stringer; Close() error; Read(p []byte) (n int, err error) }", false},
+		{"Generic instantiation", "Optional[int]", false, "originalPackage.Optional[int]", true},
+		{"Generic instantiation with several arguments", "Pair[string, *Status]", true, "*originalPackage.Pair[string, *originalPackage.Status]", true},
+		{"Qualified generic instantiation", "atomic.Pointer[Status]", false, "atomic.Pointer[originalPackage.Status]", true},
+		{"Parenthesized type", "[](*int)", false, "[](*int)", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parser.ParseExpr(tt.source)
+			assert.NoError(t, err)
+
+			v := &Format{}
+			assert.Equal(t, tt.expected, v.FieldType(expr, tt.pointer))
+			assert.Equal(t, tt.customType, v.CustomType)
+
+			// The rendered type must be valid Go
+			_, err = parser.ParseExpr(tt.expected)
+			assert.NoError(t, err)
+		})
+	}
+}
+
 func TestVersion_IdentifyVersions(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -165,10 +224,10 @@ func TestVersion_IdentifyVersions(t *testing.T) {
 		{
 			name: "Basic test",
 			expected: map[int][]string{
-				1: {"InEveryVersion string", "OnlyIn1 int", "FromStartTo3 []byte", "From1to4 float32"},
+				1: {"InEveryVersion string", "OnlyIn1 int", "FromStartTo3 []byte", "From1to4 float32", "In1And3To4 bool"},
 				2: {"InEveryVersion string", "From2ToEnd uint8", "FromStartTo3 []byte", "From1to4 float32"},
-				3: {"InEveryVersion string", "From2ToEnd uint8", "FromStartTo3 []byte", "From1to4 float32"},
-				4: {"InEveryVersion string", "From2ToEnd uint8", "From1to4 float32"},
+				3: {"InEveryVersion string", "From2ToEnd uint8", "FromStartTo3 []byte", "From1to4 float32", "In1And3To4 bool"},
+				4: {"InEveryVersion string", "From2ToEnd uint8", "From1to4 float32", "In1And3To4 bool"},
 				5: {"InEveryVersion string", "From2ToEnd uint8", "OnlyIn5 int32"},
 			},
 		},
@@ -210,6 +269,11 @@ func TestVersion_IdentifyVersions(t *testing.T) {
 							Type:  &ast.Ident{Name: "int32"},
 							Tag:   &ast.BasicLit{Value: "`version:\"5\" json:\"only_in_5\"`"},
 						},
+						{
+							Names: []*ast.Ident{{Name: "In1And3To4"}},
+							Type:  &ast.Ident{Name: "bool"},
+							Tag:   &ast.BasicLit{Value: "`version:\"1,3-4\" json:\"in_1_and_3_to_4\"`"},
+						},
 					},
 				},
 			}
@@ -231,6 +295,10 @@ func TestVersion_ParseVersionTag(t *testing.T) {
 		{"Range", "1-3", 5, []int{1, 2, 3}},
 		{"Open-ended", "2+", 3, []int{2, 3}},
 		{"Invalid tag", "abc", 3, []int{}},
+		{"Version list", "1,3,5-7", 8, []int{1, 3, 5, 6, 7}},
+		{"Overlapping list", "4+,1-2,2", 5, []int{1, 2, 4, 5}},
+		{"List with spaces", "1, 3", 3, []int{1, 3}},
+		{"Discriminator", "discriminator", 3, []int{1, 2, 3}},
 	}
 
 	v := Format{}
@@ -251,6 +319,9 @@ func TestVersion_DetermineMaxVersion(t *testing.T) {
 		{"Single version", []string{"1"}, 1},
 		{"Range", []string{"1-3"}, 3},
 		{"Multiple tags", []string{"1", "2", "3-4"}, 4},
+		{"Version list", []string{"1,3,5-7", "2+"}, 7},
+		{"Open range past the others", []string{"1", "2+"}, 2},
+		{"Closed range past the others", []string{"1-3", "7-9"}, 9},
 	}
 
 	for _, tt := range tests {
@@ -305,6 +376,26 @@ func TestVersion_ParseVersionRange(t *testing.T) {
 			wantEnd:   1,
 			wantErr:   false,
 		},
+		{
+			name:    "Double plus",
+			tag:     "2++",
+			wantErr: true,
+		},
+		{
+			name:    "Signed version",
+			tag:     "+3",
+			wantErr: true,
+		},
+		{
+			name:    "Too many dashes",
+			tag:     "1-2-3",
+			wantErr: true,
+		},
+		{
+			name:    "Missing upper bound",
+			tag:     "2-",
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -333,6 +424,10 @@ func TestVersion_ExcludeVersionTag(t *testing.T) {
 	}{
 		{"Single version tag", `version:"1" json:"field1"`, `json:"field1"`},
 		{"Multiple tags", `json:"field1" version:"1-2" xml:"field1"`, `json:"field1" xml:"field1"`},
+		{"Spaces in the version", `json:"back" version:"1, 3"`, `json:"back"`},
+		{"Spaces in other values", `validate:"required min=1" version:"2+"  xml:"field1"`, `validate:"required min=1" xml:"field1"`},
+		{"Escaped quotes", `version:"1" doc:"a \"quoted\" word"`, `doc:"a \"quoted\" word"`},
+		{"Keys starting like version", `versions:"1" version:"2"`, `versions:"1"`},
 	}
 
 	for _, tt := range tests {
@@ -343,3 +438,198 @@ func TestVersion_ExcludeVersionTag(t *testing.T) {
 		})
 	}
 }
+
+func TestVersion_HasVersionTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   []*ast.Field
+		expected bool
+	}{
+		{
+			name: "Version tag",
+			fields: []*ast.Field{
+				{Names: []*ast.Ident{{Name: "Field1"}}, Type: &ast.Ident{Name: "string"}},
+				{Names: []*ast.Ident{{Name: "Field2"}}, Type: &ast.Ident{Name: "int"}, Tag: &ast.BasicLit{Value: "`json:\"field2\" version:\"2+\"`"}},
+			},
+			expected: true,
+		},
+		{
+			name: "Other tags only",
+			fields: []*ast.Field{
+				{Names: []*ast.Ident{{Name: "Field1"}}, Type: &ast.Ident{Name: "string"}, Tag: &ast.BasicLit{Value: "`json:\"field1\"`"}},
+			},
+			expected: false,
+		},
+		{
+			name:     "No fields",
+			fields:   nil,
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &Format{}
+			result := v.HasVersionTags(&ast.StructType{Fields: &ast.FieldList{List: tt.fields}})
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestVersion_ValidateVersionTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected []string
+	}{
+		{
+			name: "Valid tags",
+			source: "package example\n\ntype Valid struct {\n" +
+				"\tA string `version:\"1\"`\n" +
+				"\tB string `json:\"b\" version:\"2+\"`\n" +
+				"\tC string `version:\"-3\"`\n" +
+				"\tD string `version:\"\"`\n" +
+				"\tE string `json:\"e\"`\n" +
+				"}\n",
+		},
+		{
+			name: "Field added in the last version",
+			source: "package example\n\ntype Added struct {\n" +
+				"\tA int `version:\"1\"`\n" +
+				"\tB int `version:\"2+\"`\n" +
+				"}\n",
+		},
+		{
+			name: "Ranges past the other fields add versions",
+			source: "package example\n\ntype Past struct {\n" +
+				"\tA int `version:\"1-3\"`\n" +
+				"\tB int `version:\"7-9\"`\n" +
+				"\tC int `version:\"12+\"`\n" +
+				"}\n",
+		},
+		{
+			name: "Every problem is reported",
+			source: "package example\n\ntype Invalid struct {\n" +
+				"\tA string `version:\"2++\"`\n" +
+				"\tB string `json:\"b\" version:\"3-1\"`\n" +
+				"\tC string `version:\"0\"`\n" +
+				"\tD string `version:\"7+\"`\n" +
+				"\tE, F string `version:\"1-4\"`\n" +
+				"\tG string `version:\"0-2\"`\n" +
+				"}\n",
+			expected: []string{
+				`example.go:4:21: field A: invalid version tag "2++": cannot parse range: "2+" is not a version number`,
+				`example.go:5:30: field B: invalid version tag "3-1": range is inverted, 3 is greater than 1`,
+				`example.go:6:21: field C: invalid version tag "0": versions start at 1`,
+				`example.go:9:21: field G: invalid version tag "0-2": versions start at 1`,
+			},
+		},
+		{
+			name: "Version lists",
+			source: "package example\n\ntype Lists struct {\n" +
+				"\tA string `version:\"1,3,5-7\"`\n" +
+				"\tB string `version:\"1,,3\"`\n" +
+				"\tC string `version:\"2,x,6-4\"`\n" +
+				"}\n",
+			expected: []string{
+				`example.go:5:21: field B: invalid version tag "1,,3": "": empty entry in version list`,
+				`example.go:6:21: field C: invalid version tag "2,x,6-4": "x": cannot parse range: "x" is not a version number`,
+				`example.go:6:21: field C: invalid version tag "2,x,6-4": "6-4": range is inverted, 6 is greater than 4`,
+			},
+		},
+		{
+			name: "Discriminators",
+			source: "package example\n\ntype Discriminators struct {\n" +
+				"\tA int `version:\"discriminator\"`\n" +
+				"\tB int `version:\"discriminator\"`\n" +
+				"\tC, D int `version:\"discriminator\"`\n" +
+				"\tE string `version:\"2\"`\n" +
+				"\tF string `version:\"discriminator\"`\n" +
+				"\tG *int `version:\"discriminator\"`\n" +
+				"}\n",
+			expected: []string{
+				`example.go:5:18: field B: invalid version tag "discriminator": the struct already has a discriminator`,
+				`example.go:6:21: field C, D: invalid version tag "discriminator": the discriminator must be a single named field`,
+				`example.go:8:21: field F: invalid version tag "discriminator": the discriminator must be an integer, not string`,
+				`example.go:9:19: field G: invalid version tag "discriminator": the discriminator must be an integer, not *int`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileSet := token.NewFileSet()
+			node, err := parser.ParseFile(fileSet, "example.go", tt.source, 0)
+			assert.NoError(t, err)
+			structType := node.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+
+			v := &Format{}
+			err = v.ValidateVersionTags(fileSet, structType)
+			if len(tt.expected) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			var messages []string
+			for _, tagErr := range err.(VersionTagErrors) {
+				messages = append(messages, tagErr.Error())
+			}
+			assert.Equal(t, tt.expected, messages)
+		})
+	}
+}
+
+func TestVersion_SplitVersionTag(t *testing.T) {
+	tests := []struct {
+		name     string
+		tag      string
+		expected []string
+	}{
+		{"Single range", "2+", []string{"2+"}},
+		{"Version list", "1,3,5-7", []string{"1", "3", "5-7"}},
+		{"Spaces", " 1 , -3 ", []string{"1", "-3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &Format{}
+			assert.Equal(t, tt.expected, v.SplitVersionTag(tt.tag))
+		})
+	}
+}
+
+func TestVersion_FieldNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		field    *ast.Field
+		expected []string
+	}{
+		{
+			name:     "Named field",
+			field:    &ast.Field{Names: []*ast.Ident{{Name: "Name"}}, Type: &ast.Ident{Name: "string"}},
+			expected: []string{"Name"},
+		},
+		{
+			name:     "Embedded type",
+			field:    &ast.Field{Type: &ast.Ident{Name: "Audit"}},
+			expected: []string{"Audit"},
+		},
+		{
+			name:     "Embedded pointer to qualified type",
+			field:    &ast.Field{Type: &ast.StarExpr{X: &ast.SelectorExpr{X: &ast.Ident{Name: "shared"}, Sel: &ast.Ident{Name: "Timestamps"}}}},
+			expected: []string{"Timestamps"},
+		},
+		{
+			name:     "Embedded generic type",
+			field:    &ast.Field{Type: &ast.IndexExpr{X: &ast.Ident{Name: "Base"}, Index: &ast.Ident{Name: "int"}}},
+			expected: []string{"Base"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &Format{}
+			assert.Equal(t, tt.expected, v.FieldNames(tt.field))
+		})
+	}
+}
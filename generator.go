@@ -1,13 +1,16 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/gerardforcada/structera/helpers"
 	"github.com/gerardforcada/structera/templates"
+	"github.com/stoewer/go-strcase"
 	"go/ast"
+	"go/format"
 	"go/parser"
 	"go/token"
-	"os"
+	"go/types"
 	"path"
 	"path/filepath"
 	"strings"
@@ -30,52 +33,208 @@ type Generator struct {
 	VersionedFields map[int][]HubFieldInfo
 	Package         string
 	Replace         bool
+	SkipTypes       bool
+	Check           bool
+	StaleFiles      []StaleFile
+	Hubs            []StructName
+	SourceImport    *Import
+	Output          *Output
+	Source          string
+	SourceName      string
+	Release         string
+	HTML            bool
+}
+
+func NewGenerator(fileName, structName, outputDir, pkg string, replace bool) *Generator {
+	if outputDir == "" {
+		outputDir = filepath.Dir(fileName)
+	}
+
+	return &Generator{
+		Format:   &Format{},
+		Resolver: &Resolver{},
+		Filename: fileName,
+		StructName: StructName{
+			Original: structName,
+			Lower:    strings.ToLower(structName),
+			Snake:    strcase.SnakeCase(structName),
+		},
+		OutputDir: outputDir,
+		Package:   pkg,
+		Replace:   replace,
+		Output:    &Output{},
+	}
 }
 
 type GenerateFileFromTemplateInput struct {
 	TemplateFilePath string
 	OutputFilePath   string
+	Source           string
+	Era              bool // Kept as it is on disk unless forced
 	Data             any
 }
 
 func (g *Generator) FileFromTemplate(input GenerateFileFromTemplateInput) error {
-	// Ensure the directory for the output file exists
-	outputDir := filepath.Dir(input.OutputFilePath)
-	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+	tmpl, err := template.New(filepath.Base(input.TemplateFilePath)).Funcs(template.FuncMap{"sub": helpers.Sub, "cell": helpers.MarkdownCell, "code": helpers.MarkdownCode}).ParseFS(templates.FS, input.TemplateFilePath)
+	if err != nil {
 		return err
 	}
 
-	tmpl, err := template.New(filepath.Base(input.TemplateFilePath)).Funcs(template.FuncMap{"sub": helpers.Sub}).ParseFS(templates.FS, input.TemplateFilePath)
-	if err != nil {
+	// Render the template in memory with the data
+	var content bytes.Buffer
+	if err = tmpl.Execute(&content, input.Data); err != nil {
+		return err
+	}
+
+	// Only Go files are formatted, documents are rendered as they are
+	formatted := content.Bytes()
+	if filepath.Ext(input.OutputFilePath) == ".go" {
+		if formatted, err = format.Source(formatted); err != nil {
+			return fmt.Errorf("error formatting %s: %v", input.OutputFilePath, err)
+		}
+	}
+
+	generated := withHeader(commentStyleFor(input.OutputFilePath), input.Source, formatted)
+
+	if g.Check {
+		if input.Era && !g.Replace {
+			return g.CompareEra(input.OutputFilePath, generated)
+		}
+		return g.CompareFile(input.OutputFilePath, generated)
+	}
+
+	// Edits to a generated file are only lost on purpose
+	if err := protectHandModified(input.OutputFilePath, g.Replace); err != nil {
 		return err
 	}
 
-	// Create the output file
-	file, err := os.Create(input.OutputFilePath)
+	// Nothing is written until every file is rendered, see Flush
+	if g.Output == nil {
+		g.Output = &Output{}
+	}
+	g.Output.Add(input.OutputFilePath, generated)
+	return nil
+}
+
+// Flush type-checks the rendered files and writes them all at once.
+func (g *Generator) Flush() error {
+	if g.Output == nil {
+		return nil
+	}
+	return g.Output.Flush()
+}
+
+// ParseStruct parses the source file and finds the struct to version.
+func (g *Generator) ParseStruct() (*token.FileSet, *ast.File, *ast.StructType, error) {
+	fileSet := token.NewFileSet()
+	node, err := parser.ParseFile(fileSet, g.Filename, nil, parser.ParseComments)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	for _, f := range node.Decls {
+		genDecl, ok := f.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok || typeSpec.Name.Name != g.StructName.Original {
+				continue
+			}
+
+			structType, ok := typeSpec.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+
+			// The hub, its eras and the types table would all need instantiating
+			if typeSpec.TypeParams != nil {
+				return nil, nil, nil, fmt.Errorf("%s: struct '%s' is generic, which versioned structs don't support; version a struct holding an instance of it instead, like %s[int]", fileSet.Position(typeSpec.TypeParams.Pos()), g.StructName.Original, g.StructName.Original)
+			}
+			return fileSet, node, structType, nil
+		}
+	}
+
+	return nil, nil, nil, fmt.Errorf("struct '%s' not found in file '%s'", g.StructName.Original, g.Filename)
+}
+
+// Validate reports every malformed version tag of the struct without generating anything.
+func (g *Generator) Validate() error {
+	fileSet, _, structType, err := g.ParseStruct()
 	if err != nil {
 		return err
 	}
-	defer func(file *os.File) {
-		err = file.Close()
-		if err != nil {
-			fmt.Printf("error closing file: %v\n", err)
+
+	return g.Format.ValidateVersionTags(fileSet, structType)
+}
+
+// ValidateEmbeddedFields refuses embedded interfaces: the hub holds every
+// embedded field that isn't a pointer behind one, and Go can't embed a pointer
+// to an interface.
+func (g *Generator) ValidateEmbeddedFields(fileSet *token.FileSet, structType *ast.StructType) error {
+	// Embedded fields that aren't pointers, by their index in the struct type
+	var indexes []int
+	var embedded []*ast.Field
+	index := 0
+	for _, field := range structType.Fields.List {
+		if len(field.Names) > 0 {
+			index += len(field.Names)
+			continue
 		}
-	}(file)
+		if _, isPointer := field.Type.(*ast.StarExpr); !isPointer {
+			indexes = append(indexes, index)
+			embedded = append(embedded, field)
+		}
+		index++
+	}
+	if len(embedded) == 0 {
+		return nil
+	}
+
+	// Whether a type is an interface takes its declaration, in the package of the struct or another one
+	sourceDir, err := filepath.Abs(filepath.Dir(g.Filename))
+	if err != nil {
+		return err
+	}
+	sourcePath, err := g.sourcePackage()
+	if err != nil {
+		return err
+	}
+	pkg, err := newSourceImporter(sourceDir).Import(sourcePath)
+	if err != nil || pkg == nil {
+		return nil // Left to the type-check of the generated code
+	}
+	object, ok := pkg.Scope().Lookup(g.StructName.Original).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	structure, ok := object.Type().Underlying().(*types.Struct)
+	if !ok || structure.NumFields() != index {
+		return nil
+	}
 
-	// Execute the template with the data
-	return tmpl.Execute(file, input.Data)
+	for i, field := range embedded {
+		if types.IsInterface(structure.Field(indexes[i]).Type()) {
+			return fmt.Errorf("%s: embedded field %s is an interface, which the hub can't hold as an optional field; give the field a name instead", fileSet.Position(field.Type.Pos()), g.Format.fieldName(field))
+		}
+	}
+	return nil
 }
 
 func (g *Generator) VersionedStructs() error {
-	fileSet := token.NewFileSet()
-	node, err := parser.ParseFile(fileSet, g.Filename, nil, parser.ParseComments)
+	fileSet, node, structType, err := g.ParseStruct()
 	if err != nil {
 		return err
 	}
 
-	var imports []string
-	for _, i := range node.Imports {
-		imports = append(imports, strings.Trim(i.Path.Value, "\""))
+	if err := g.Format.ValidateVersionTags(fileSet, structType); err != nil {
+		return err
+	}
+
+	if err := g.fingerprint(fileSet, structType); err != nil {
+		return err
 	}
 
 	err = g.Resolver.FindGoModPath(filepath.Dir(g.Filename))
@@ -90,72 +249,102 @@ func (g *Generator) VersionedStructs() error {
 
 	// Calculate the relative path from the go.mod directory to the generated file directory
 	goModDir := filepath.Dir(g.Resolver.GoModPath)
-	relativePath, err := filepath.Rel(goModDir, g.OutputDir)
+	outputDir, err := filepath.Abs(g.OutputDir)
+	if err != nil {
+		return err
+	}
+	relativePath, err := filepath.Rel(goModDir, outputDir)
 	if err != nil {
 		return err
 	}
 
 	importPath := path.Join(g.Resolver.ImportPath, relativePath)
 
-	for _, f := range node.Decls {
-		genDecl, ok := f.(*ast.GenDecl)
-		if !ok || genDecl.Tok != token.TYPE {
-			continue
-		}
+	if err := g.ValidateEmbeddedFields(fileSet, structType); err != nil {
+		return err
+	}
 
-		for _, spec := range genDecl.Specs {
-			typeSpec, ok := spec.(*ast.TypeSpec)
-			if !ok || typeSpec.Name.Name != g.StructName.Original {
-				continue
-			}
+	// Each generated file only imports the packages its fields refer to
+	imports, err := g.ResolveImports(node, structType, importPath)
+	if err != nil {
+		return err
+	}
 
-			structType, ok := typeSpec.Type.(*ast.StructType)
-			if !ok {
-				continue
-			}
+	// Types declared next to the struct are qualified with an alias free in every generated file
+	g.Format.SourceAlias = g.sourceAlias(imports, importPath)
 
-			g.Format.IdentifyVersions(structType)
-			if len(g.Format.Versions) == 0 {
-				return fmt.Errorf("no version tags found in struct")
-			}
+	g.Format.IdentifyVersions(structType)
+	if len(g.Format.Versions) == 0 {
+		return fmt.Errorf("no version tags found in struct")
+	}
 
-			fields, maxNameLength, err := g.ProcessFieldInfo(structType)
-			if err != nil {
-				return err
-			}
+	fields, maxNameLength, err := g.ProcessFieldInfo(fileSet, structType)
+	if err != nil {
+		return err
+	}
 
-			// Second pass to add padding for alignment
-			for i := range fields {
-				padding := maxNameLength - len(fields[i].Name)
-				fields[i].FormattedName = fields[i].Name + strings.Repeat(" ", padding)
-			}
+	// Second pass to add padding for alignment
+	for i := range fields {
+		padding := maxNameLength - len(fields[i].Name)
+		fields[i].FormattedName = fields[i].Name + strings.Repeat(" ", padding)
+	}
 
-			g.ProcessedFields = fields
-			g.PrepareVersionedFields()
+	g.ProcessedFields = fields
+	g.PrepareVersionedFields()
 
-			// Generate versioned struct files
-			err = g.HubFile(imports, importPath)
-			if err != nil {
-				return err
-			}
+	if err := g.resolveSourceImport(node, importPath); err != nil {
+		return err
+	}
 
-			for version, fields := range g.VersionedFields {
-				err = g.EraFile(imports, version, fields)
-				if err != nil {
-					return err
-				}
-			}
-			// Generate types.go file
-			err = g.TypesFile(importPath)
-			if err != nil {
-				return err
-			}
+	// Released eras can't change anymore
+	if err := g.LockEras(imports); err != nil {
+		return err
+	}
+
+	// The hub and the migrations have to match the eras kept on disk
+	if err := g.ValidateSkippedEras(); err != nil {
+		return err
+	}
+
+	// Generate versioned struct files
+	err = g.HubFile(imports, importPath)
+	if err != nil {
+		return err
+	}
+
+	if err := g.ChangelogFile(); err != nil {
+		return err
+	}
 
-			return nil
+	for _, version := range g.Format.SortedVersions {
+		err = g.EraFile(imports, version, g.VersionedFields[version])
+		if err != nil {
+			return err
 		}
 	}
 
-	return fmt.Errorf("struct '%s' not found in file '%s'", g.StructName.Original, g.Filename)
+	if err := g.MigrateFile(); err != nil {
+		return err
+	}
+
+	// Generate types.go file and write everything, unless the caller does it once for several hubs
+	if g.SkipTypes {
+		return nil
+	}
+	if err := g.TypesFile(importPath); err != nil {
+		return err
+	}
+	return g.Flush()
+}
+
+// fieldDoc returns the doc comment of the field, or else its line comment,
+// on a single line.
+func fieldDoc(field *ast.Field) string {
+	comment := field.Doc
+	if comment == nil {
+		comment = field.Comment
+	}
+	return strings.Join(strings.Fields(comment.Text()), " ")
 }
 
 func (g *Generator) PrepareVersionedFields() {
@@ -168,11 +357,11 @@ func (g *Generator) PrepareVersionedFields() {
 			if len(parts) < 2 {
 				continue
 			}
-			fieldName := parts[0]
+			fieldName, fieldType := parts[0], parts[1]
 
 			for _, field := range g.ProcessedFields {
 				if field.Name == fieldName {
-					field.Type = field.Type[1:] // remove first char of field type (asterisk)
+					field.Type = fieldType // the era keeps the original type, without the hub pointer
 					versionFieldInfos = append(versionFieldInfos, field)
 					break
 				}
@@ -185,36 +374,48 @@ func (g *Generator) PrepareVersionedFields() {
 	g.VersionedFields = versionedFields
 }
 
-func (g *Generator) ProcessFieldInfo(structType *ast.StructType) ([]HubFieldInfo, int, error) {
+func (g *Generator) ProcessFieldInfo(fileSet *token.FileSet, structType *ast.StructType) ([]HubFieldInfo, int, error) {
 	var fields []HubFieldInfo
 	maxNameLength := 0
 
 	for _, field := range structType.Fields.List {
-		if len(field.Names) == 0 {
-			continue
-		}
+		embedded := len(field.Names) == 0
 
-		fieldName := field.Names[0].Name
-		fieldType := g.Format.FieldType(field.Type, true)
-
-		fieldInfo := HubFieldInfo{
-			Name: fieldName,
-			Type: fieldType,
+		// The generated packages can only refer to the exported names of the source package
+		if ident := g.Format.UnexportedIdent(field.Type); ident != nil {
+			position := token.Position{}
+			if fileSet != nil {
+				position = fileSet.Position(ident.Pos())
+			}
+			return nil, 0, fmt.Errorf("%s: field %s uses %s, which is unexported in the package of the struct, so the generated code can't refer to it; export it", position, g.Format.fieldName(field), ident.Name)
 		}
 
+		// An embedded field can't be a pointer to a pointer, so embedded pointers are kept as they are
+		_, isPointer := field.Type.(*ast.StarExpr)
+		fieldType := g.Format.FieldType(field.Type, !(embedded && isPointer))
+		qualifiers := g.Format.FieldQualifiers(field.Type)
+
+		tag := ""
 		if field.Tag != nil {
 			tagValue := field.Tag.Value
-			tag := tagValue[1 : len(tagValue)-1] // Extract tag string without quotes
-			filteredTag := g.Format.ExcludeVersionTag(tag)
-			if filteredTag != "" {
-				fieldInfo.Tag = filteredTag
-			}
+			tag = g.Format.ExcludeVersionTag(tagValue[1 : len(tagValue)-1]) // Extract tag string without quotes
 		}
 
-		fields = append(fields, fieldInfo)
-
-		if len(fieldName) > maxNameLength {
-			maxNameLength = len(fieldName)
+		// Every name of a multi-name declaration (A, B int) becomes its own field
+		for _, fieldName := range g.Format.FieldNames(field) {
+			fields = append(fields, HubFieldInfo{
+				Name:          fieldName,
+				Type:          fieldType,
+				Tag:           tag,
+				Embedded:      embedded,
+				Qualifiers:    qualifiers,
+				Doc:           fieldDoc(field),
+				Discriminator: g.Format.IsDiscriminator(field),
+			})
+
+			if len(fieldName) > maxNameLength {
+				maxNameLength = len(fieldName)
+			}
 		}
 	}
 
@@ -3,6 +3,9 @@ package main
 import (
 	"github.com/stretchr/testify/assert"
 	"go/ast"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -31,6 +34,26 @@ func TestGenerator_PrepareVersionedFields(t *testing.T) {
 				2: {{Name: "Field2", Type: "int"}, {Name: "Field3", Type: "float64"}},
 			},
 		},
+		{
+			name: "Embedded Fields",
+			format: &Format{
+				Versions: map[int][]string{
+					1: {"Name string", "Audit shared.Audit", "Timestamps *shared.Timestamps"},
+				},
+			},
+			processedFields: []HubFieldInfo{
+				{Name: "Name", Type: "*string"},
+				{Name: "Audit", Type: "*shared.Audit", Embedded: true},
+				{Name: "Timestamps", Type: "*shared.Timestamps", Embedded: true},
+			},
+			want: map[int][]HubFieldInfo{
+				1: {
+					{Name: "Name", Type: "string"},
+					{Name: "Audit", Type: "shared.Audit", Embedded: true},
+					{Name: "Timestamps", Type: "*shared.Timestamps", Embedded: true},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -77,6 +100,7 @@ func TestGenerator_ProcessFieldInfo(t *testing.T) {
 					},
 				},
 			},
+			format: &Format{},
 			expectedFields: []HubFieldInfo{
 				{Name: "Field1", Type: "*string", Tag: "json:\"field1\""},
 				{Name: "Field2", Type: "*int"},
@@ -84,6 +108,81 @@ func TestGenerator_ProcessFieldInfo(t *testing.T) {
 			},
 			expectedMaxLen: 6,
 		},
+		{
+			name: "Embedded Fields",
+			structType: &ast.StructType{
+				Fields: &ast.FieldList{
+					List: []*ast.Field{
+						{
+							Names: []*ast.Ident{{Name: "Name"}},
+							Type:  &ast.Ident{Name: "string"},
+						},
+						{
+							Type: &ast.SelectorExpr{X: &ast.Ident{Name: "shared"}, Sel: &ast.Ident{Name: "Audit"}},
+							Tag:  &ast.BasicLit{Value: "`version:\"2+\"`"},
+						},
+						{
+							Type: &ast.StarExpr{X: &ast.SelectorExpr{X: &ast.Ident{Name: "shared"}, Sel: &ast.Ident{Name: "Timestamps"}}},
+						},
+					},
+				},
+			},
+			format: &Format{},
+			expectedFields: []HubFieldInfo{
+				{Name: "Name", Type: "*string"},
+				{Name: "Audit", Type: "*shared.Audit", Embedded: true, Qualifiers: []string{"shared"}},
+				{Name: "Timestamps", Type: "*shared.Timestamps", Embedded: true, Qualifiers: []string{"shared"}},
+			},
+			expectedMaxLen: 10,
+		},
+		{
+			name: "Multi-name Fields",
+			structType: &ast.StructType{
+				Fields: &ast.FieldList{
+					List: []*ast.Field{
+						{
+							Names: []*ast.Ident{{Name: "ID"}},
+							Type:  &ast.Ident{Name: "int"},
+						},
+						{
+							Names: []*ast.Ident{{Name: "First"}, {Name: "Last"}},
+							Type:  &ast.Ident{Name: "string"},
+							Tag:   &ast.BasicLit{Value: "`version:\"2+\" json:\",omitempty\"`"},
+						},
+					},
+				},
+			},
+			format: &Format{},
+			expectedFields: []HubFieldInfo{
+				{Name: "ID", Type: "*int"},
+				{Name: "First", Type: "*string", Tag: "json:\",omitempty\""},
+				{Name: "Last", Type: "*string", Tag: "json:\",omitempty\""},
+			},
+			expectedMaxLen: 5,
+		},
+		{
+			name: "Source Package Types",
+			structType: &ast.StructType{
+				Fields: &ast.FieldList{
+					List: []*ast.Field{
+						{
+							Names: []*ast.Ident{{Name: "Status"}},
+							Type:  &ast.Ident{Name: "Status"},
+						},
+						{
+							Names: []*ast.Ident{{Name: "Labels"}},
+							Type:  &ast.MapType{Key: &ast.Ident{Name: "string"}, Value: &ast.Ident{Name: "Label"}},
+						},
+					},
+				},
+			},
+			format: &Format{SourceAlias: "models"},
+			expectedFields: []HubFieldInfo{
+				{Name: "Status", Type: "*models.Status", Qualifiers: []string{"models"}},
+				{Name: "Labels", Type: "*map[string]models.Label", Qualifiers: []string{"models"}},
+			},
+			expectedMaxLen: 6,
+		},
 	}
 
 	for _, tt := range tests {
@@ -92,10 +191,310 @@ func TestGenerator_ProcessFieldInfo(t *testing.T) {
 				Format: tt.format,
 			}
 
-			fields, maxLen, err := g.ProcessFieldInfo(tt.structType)
+			fields, maxLen, err := g.ProcessFieldInfo(nil, tt.structType)
 			assert.NoError(t, err)
 			assert.Equal(t, tt.expectedFields, fields)
 			assert.Equal(t, tt.expectedMaxLen, maxLen)
 		})
 	}
 }
+
+func TestGenerator_VersionedStructs_MultiNameFields(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	source := "package models\n\ntype Person struct {\n\tID          int\n\tFirst, Last string `version:\"2-3\" json:\",omitempty\"`\n}\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte(goMod(t, "example.com/multi")), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "person.go"), []byte(source), 0644))
+
+	g := NewGenerator(filepath.Join(tempDir, "person.go"), "Person", "", string(ModuleFolder), false)
+	assert.NoError(t, g.VersionedStructs())
+
+	hub, err := os.ReadFile(filepath.Join(tempDir, string(ModuleFolder), "person.go"))
+	assert.NoError(t, err)
+	assert.Regexp(t, `First\s+\*string`, string(hub))
+	assert.Regexp(t, `Last\s+\*string`, string(hub))
+
+	v1, err := os.ReadFile(filepath.Join(tempDir, string(ModuleFolder), "person", "v1.go"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(v1), "First")
+
+	v2, err := os.ReadFile(filepath.Join(tempDir, string(ModuleFolder), "person", "v2.go"))
+	assert.NoError(t, err)
+	assert.Regexp(t, "First\\s+string `json:\",omitempty\"`", string(v2))
+	assert.Regexp(t, "Last\\s+string `json:\",omitempty\"`", string(v2))
+}
+
+func TestGenerator_VersionedStructs_Tags(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	source := "package models\n\ntype Person struct {\n" +
+		"\tID   int\n" +
+		"\tBack int    `json:\"back\" version:\"1, 3\"`\n" +
+		"\tRule string `validate:\"required min=1\" version:\"2+\" xml:\"rule\"`\n" +
+		"}\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte(goMod(t, "example.com/tags")), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "person.go"), []byte(source), 0644))
+
+	g := NewGenerator(filepath.Join(tempDir, "person.go"), "Person", "", string(ModuleFolder), false)
+	assert.NoError(t, g.VersionedStructs())
+
+	// The version key is gone, and the other keys are kept whole
+	hub, err := os.ReadFile(filepath.Join(tempDir, string(ModuleFolder), "person.go"))
+	assert.NoError(t, err)
+	assert.Regexp(t, "Back\\s+\\*int\\s+`json:\"back\"`\n", string(hub))
+	assert.Regexp(t, "Rule\\s+\\*string\\s+`validate:\"required min=1\" xml:\"rule\"`\n", string(hub))
+
+	v3, err := os.ReadFile(filepath.Join(tempDir, string(ModuleFolder), "person", "v3.go"))
+	assert.NoError(t, err)
+	assert.Regexp(t, "Back\\s+int\\s+`json:\"back\"`\n", string(v3))
+	assert.Regexp(t, "Rule\\s+string\\s+`validate:\"required min=1\" xml:\"rule\"`\n", string(v3))
+
+	v2, err := os.ReadFile(filepath.Join(tempDir, string(ModuleFolder), "person", "v2.go"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(v2), "Back")
+}
+
+func TestGenerator_VersionedStructs_Generics(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	source := "package models\n\n" +
+		"type Box[T any] struct {\n\tV T `version:\"1\"`\n}\n\n" +
+		"type Shelf struct {\n\tBoxes []Box[int]\n\tLabel Box[string] `version:\"2\"`\n}\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte(goMod(t, "example.com/generics")), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "box.go"), []byte(source), 0644))
+
+	// A generic struct is refused before anything is generated
+	g := NewGenerator(filepath.Join(tempDir, "box.go"), "Box", "", string(ModuleFolder), false)
+	assert.ErrorContains(t, g.VersionedStructs(), "box.go:3:9: struct 'Box' is generic, which versioned structs don't support")
+	assert.NoDirExists(t, filepath.Join(tempDir, string(ModuleFolder)))
+
+	// Structs holding instances of it are versioned, and type-check
+	g = NewGenerator(filepath.Join(tempDir, "box.go"), "Shelf", "", string(ModuleFolder), false)
+	assert.NoError(t, g.VersionedStructs())
+
+	hub, err := os.ReadFile(filepath.Join(tempDir, string(ModuleFolder), "shelf.go"))
+	assert.NoError(t, err)
+	assert.Regexp(t, `Boxes\s+\*\[\]originalPackage\.Box\[int\]`, string(hub))
+	assert.Regexp(t, `Label\s+\*originalPackage\.Box\[string\]`, string(hub))
+
+	v2, err := os.ReadFile(filepath.Join(tempDir, string(ModuleFolder), "shelf", "v2.go"))
+	assert.NoError(t, err)
+	assert.Regexp(t, `Label\s+originalPackage\.Box\[string\]`, string(v2))
+}
+
+func TestGenerator_VersionedStructs_EmbeddedInterfaces(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	source := "package models\n\nimport \"fmt\"\n\n" +
+		"type Named interface {\n\tName() string\n}\n\n" +
+		"type Base struct {\n\tID int\n}\n\n" +
+		"type Printable struct {\n\tBase\n\tfmt.Stringer `version:\"2+\"`\n}\n\n" +
+		"type Entity struct {\n\tID, Rank int\n\tNamed\n\tLabel string `version:\"2\"`\n}\n\n" +
+		"type Holder struct {\n\tBase\n\tStringer fmt.Stringer `version:\"2+\"`\n}\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte(goMod(t, "example.com/embedded")), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "models.go"), []byte(source), 0644))
+
+	// Embedded interfaces, of another package or the same one, are refused before anything is generated
+	g := NewGenerator(filepath.Join(tempDir, "models.go"), "Printable", "", string(ModuleFolder), false)
+	assert.EqualError(t, g.VersionedStructs(), filepath.Join(tempDir, "models.go")+":15:2: embedded field Stringer is an interface, which the hub can't hold as an optional field; give the field a name instead")
+	g = NewGenerator(filepath.Join(tempDir, "models.go"), "Entity", "", string(ModuleFolder), false)
+	assert.ErrorContains(t, g.VersionedStructs(), "models.go:20:2: embedded field Named is an interface")
+	assert.NoDirExists(t, filepath.Join(tempDir, string(ModuleFolder)))
+
+	// Named interface fields and embedded structs are versioned, and type-check
+	g = NewGenerator(filepath.Join(tempDir, "models.go"), "Holder", "", string(ModuleFolder), false)
+	assert.NoError(t, g.VersionedStructs())
+}
+
+func TestGenerator_VersionedStructs_UnexportedTypes(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	source := "package models\n\n" +
+		"type status string\n\n" +
+		"type User struct {\n\tName   string\n\tStatus status `version:\"2\"`\n}\n\n" +
+		"type Admin struct {\n\tName  string\n\tRoles map[string][]status `version:\"2\"`\n}\n\n" +
+		"type Guest struct {\n\tName   string\n\tNotify func(id int, ok bool) error `version:\"2\"`\n\tBuffer [len(\"abc\")]byte\n}\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte(goMod(t, "example.com/unexported")), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "user.go"), []byte(source), 0644))
+
+	// Unexported types of the package are refused where they are used, before anything is generated
+	g := NewGenerator(filepath.Join(tempDir, "user.go"), "User", "", string(ModuleFolder), false)
+	assert.ErrorContains(t, g.VersionedStructs(), "user.go:7:9: field Status uses status, which is unexported in the package of the struct")
+	g = NewGenerator(filepath.Join(tempDir, "user.go"), "Admin", "", string(ModuleFolder), false)
+	assert.ErrorContains(t, g.VersionedStructs(), "user.go:12:21: field Roles uses status")
+	assert.NoDirExists(t, filepath.Join(tempDir, string(ModuleFolder)))
+
+	// Parameter names and predeclared identifiers aren't types of the package
+	g = NewGenerator(filepath.Join(tempDir, "user.go"), "Guest", "", string(ModuleFolder), false)
+	assert.NoError(t, g.VersionedStructs())
+}
+
+func TestGenerator_VersionedStructs_SourceTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+		alias   string
+	}{
+		{
+			name: "Imports the source package",
+			files: map[string]string{
+				"user.go": "package models\n\ntype Status string\n\ntype User struct {\n\tName   string\n\tStatus Status `version:\"2-3\"`\n}\n",
+			},
+			alias: "originalPackage",
+		},
+		{
+			name: "Alias taken by a source import",
+			files: map[string]string{
+				"user.go": "package models\n\nimport originalPackage \"strings\"\n\ntype Status string\n\nvar _ = originalPackage.ToUpper\n\ntype User struct {\n\tName   string\n\tStatus Status `version:\"2-3\"`\n}\n",
+			},
+			alias: "originalPackage2",
+		},
+		{
+			name: "Source package imports the generated code",
+			files: map[string]string{
+				"user.go":  "package models\n\ntype Status string\n\ntype User struct {\n\tName   string\n\tStatus Status `version:\"2-3\"`\n}\n",
+				"hooks.go": "package models\n\nimport _ \"example.com/custom/models/version\"\n",
+			},
+			wantErr: "import cycle: struct 'User' uses types of package example.com/custom/models, but",
+		},
+		{
+			name: "Types of package main",
+			files: map[string]string{
+				"user.go": "package main\n\ntype Status string\n\ntype User struct {\n\tName   string\n\tStatus Status `version:\"2-3\"`\n}\n",
+			},
+			wantErr: "package main",
+		},
+		{
+			name: "Unexported source types",
+			files: map[string]string{
+				"user.go": "package models\n\ntype status string\n\ntype User struct {\n\tName   string\n\tStatus status `version:\"2-3\"`\n}\n",
+			},
+			wantErr: "user.go:7:9: field Status uses status, which is unexported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir, err := os.MkdirTemp("", "test")
+			assert.NoError(t, err)
+			defer func(path string) {
+				err := os.RemoveAll(path)
+				assert.NoError(t, err)
+			}(tempDir)
+
+			modelsDir := filepath.Join(tempDir, "models")
+			assert.NoError(t, os.MkdirAll(modelsDir, os.ModePerm))
+			assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte(goMod(t, "example.com/custom")), 0644))
+			for name, content := range tt.files {
+				assert.NoError(t, os.WriteFile(filepath.Join(modelsDir, name), []byte(content), 0644))
+			}
+
+			g := NewGenerator(filepath.Join(modelsDir, "user.go"), "User", "", string(ModuleFolder), false)
+			err = g.VersionedStructs()
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.NoDirExists(t, filepath.Join(modelsDir, string(ModuleFolder)))
+				return
+			}
+			assert.NoError(t, err)
+
+			sourceImport := tt.alias + ` "example.com/custom/models"`
+			hub, err := os.ReadFile(filepath.Join(modelsDir, string(ModuleFolder), "user.go"))
+			assert.NoError(t, err)
+			assert.Contains(t, string(hub), sourceImport)
+			assert.Regexp(t, `Status\s+\*`+tt.alias+`\.Status`, string(hub))
+
+			v1, err := os.ReadFile(filepath.Join(modelsDir, string(ModuleFolder), "user", "v1.go"))
+			assert.NoError(t, err)
+			assert.NotContains(t, string(v1), "import")
+
+			v2, err := os.ReadFile(filepath.Join(modelsDir, string(ModuleFolder), "user", "v2.go"))
+			assert.NoError(t, err)
+			assert.Contains(t, string(v2), "import (\n\t"+sourceImport+"\n)")
+			assert.Regexp(t, `Status\s+`+tt.alias+`\.Status`, string(v2))
+		})
+	}
+}
+
+func TestGenerator_VersionedStructs_Imports(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	source := `package models
+
+import (
+	sq "database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"example.com/imports/conversor"
+)
+
+var _ = fmt.Sprint
+
+type User struct {
+	Name      string
+	CreatedAt time.Time       ` + "`version:\"1\"`" + `
+	Rule      conversor.Rule  ` + "`version:\"1\"`" + `
+	Raw       json.RawMessage ` + "`version:\"2\"`" + `
+	Nickname  sq.NullString   ` + "`version:\"2\"`" + `
+}
+`
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte(goMod(t, "example.com/imports")), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "user.go"), []byte(source), 0644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(tempDir, "conversor"), os.ModePerm))
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "conversor", "rule.go"), []byte("package conversor\n\ntype Rule struct{}\n"), 0644))
+
+	g := NewGenerator(filepath.Join(tempDir, "user.go"), "User", "", string(ModuleFolder), false)
+	assert.NoError(t, g.VersionedStructs())
+
+	hub, err := os.ReadFile(filepath.Join(tempDir, string(ModuleFolder), "user.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(hub), `"example.com/imports/version/user"`)
+	assert.Equal(t, 1, strings.Count(string(hub), `"encoding/json"`))
+	assert.Equal(t, 1, strings.Count(string(hub), `"fmt"`))
+	assert.Contains(t, string(hub), "\tsq \"database/sql\"\n")
+	assert.Contains(t, string(hub), "\tconversor2 \"example.com/imports/conversor\"\n")
+	assert.Contains(t, string(hub), "\t\"time\"\n")
+	assert.Regexp(t, `Rule\s+\*conversor2\.Rule`, string(hub))
+
+	v1, err := os.ReadFile(filepath.Join(tempDir, string(ModuleFolder), "user", "v1.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(v1), "import (\n\tconversor2 \"example.com/imports/conversor\"\n\t\"time\"\n)")
+	assert.Regexp(t, `Rule\s+conversor2\.Rule`, string(v1))
+
+	v2, err := os.ReadFile(filepath.Join(tempDir, string(ModuleFolder), "user", "v2.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(v2), "import (\n\tsq \"database/sql\"\n\t\"encoding/json\"\n)")
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	GeneratedHeader = "// " + generatedNotice
+	generatedNotice = "Code generated by structera; DO NOT EDIT."
+	sourcePrefix    = "Source: "
+	checksumPrefix  = "Checksum: sha256:"
+)
+
+// commentStyle delimits the header lines in each kind of generated file.
+type commentStyle struct {
+	open  string
+	close string
+}
+
+var (
+	goComment   = commentStyle{open: "// "}
+	htmlComment = commentStyle{open: "<!-- ", close: " -->"} // Markdown and HTML
+)
+
+func commentStyleFor(path string) commentStyle {
+	switch filepath.Ext(path) {
+	case ".md", ".html":
+		return htmlComment
+	}
+	return goComment
+}
+
+func (c commentStyle) line(text string) string {
+	return c.open + text + c.close + "\n"
+}
+
+// fingerprint records the source struct of the generator, so the header of
+// its hub and eras tells which version of the struct they come from.
+func (g *Generator) fingerprint(fileSet *token.FileSet, structType *ast.StructType) error {
+	content, err := os.ReadFile(g.Filename)
+	if err != nil {
+		return err
+	}
+	start, end := fileSet.Position(structType.Pos()).Offset, fileSet.Position(structType.End()).Offset
+
+	// The path is relative to the module, so the header is the same on every machine
+	sourcePath, err := filepath.Abs(g.Filename)
+	if err != nil {
+		return err
+	}
+	if err := g.Resolver.FindGoModPath(filepath.Dir(sourcePath)); err == nil {
+		if relativePath, err := filepath.Rel(filepath.Dir(g.Resolver.GoModPath), sourcePath); err == nil {
+			sourcePath = relativePath
+		}
+	}
+
+	g.SourceName = fmt.Sprintf("%s:%s", filepath.ToSlash(sourcePath), g.StructName.Original)
+	g.Source = fmt.Sprintf("%s sha256:%x", g.SourceName, sha256.Sum256(content[start:end]))
+	return nil
+}
+
+// withHeader prepends the generated code header to the file body, with the
+// source struct when there is one and the checksum of the body.
+func withHeader(style commentStyle, source string, body []byte) []byte {
+	var content bytes.Buffer
+	content.WriteString(style.line(generatedNotice))
+	if source != "" {
+		content.WriteString(style.line(sourcePrefix + source))
+	}
+	content.WriteString(style.line(fmt.Sprintf("%s%x", checksumPrefix, sha256.Sum256(body))))
+	content.WriteString("\n")
+	content.Write(body)
+	return content.Bytes()
+}
+
+// splitHeader returns the checksum recorded in the header of a generated
+// file and its body. It fails for files without a header.
+func splitHeader(content []byte) (string, []byte, bool) {
+	for _, style := range []commentStyle{goComment, htmlComment} {
+		if !bytes.HasPrefix(content, []byte(style.line(generatedNotice))) {
+			continue
+		}
+
+		index := bytes.Index(content, []byte("\n\n"))
+		if index < 0 {
+			return "", nil, false
+		}
+
+		for _, line := range strings.Split(string(content[:index]), "\n") {
+			line = strings.TrimSuffix(strings.TrimPrefix(line, style.open), style.close)
+			if strings.HasPrefix(line, checksumPrefix) {
+				return strings.TrimPrefix(line, checksumPrefix), content[index+2:], true
+			}
+		}
+	}
+	return "", nil, false
+}
+
+// handModified tells why a file on disk counts as written or edited by hand,
+// if it does: its body no longer matches the checksum of its header, or it
+// has no header at all, like the files written by hand or by a version of
+// Structera older than the headers. Missing files were not modified.
+func handModified(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	checksum, body, ok := splitHeader(content)
+	if !ok {
+		return "it has no generated code header", nil
+	}
+	if checksum != fmt.Sprintf("%x", sha256.Sum256(body)) {
+		return "its content no longer matches the checksum of its header", nil
+	}
+	return "", nil
+}
+
+// protectHandModified fails when the file was modified by hand, unless forced,
+// in which case it warns that the edits are overwritten.
+func protectHandModified(path string, force bool) error {
+	reason, err := handModified(path)
+	if err != nil || reason == "" {
+		return err
+	}
+	if !force {
+		return fmt.Errorf("%s was modified by hand, %s; regenerate with --force to overwrite it", path, reason)
+	}
+
+	fmt.Printf("Warning: overwriting %s, which was modified by hand: %s\n", path, reason)
+	return nil
+}
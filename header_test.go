@@ -0,0 +1,151 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestWithHeader(t *testing.T) {
+	content := withHeader(goComment, "models/user.go:User sha256:abc", []byte("package version\n"))
+	assert.Regexp(t, regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.$`), string(content))
+	assert.True(t, strings.HasPrefix(string(content), GeneratedHeader+"\n// Source: models/user.go:User sha256:abc\n// Checksum: sha256:"))
+	assert.True(t, strings.HasSuffix(string(content), "\n\npackage version\n"))
+
+	checksum, body, ok := splitHeader(content)
+	assert.True(t, ok)
+	assert.Len(t, checksum, 64)
+	assert.Equal(t, "package version\n", string(body))
+
+	_, _, ok = splitHeader([]byte("package version\n"))
+	assert.False(t, ok)
+
+	// Documents carry the same header in HTML comments
+	content = withHeader(commentStyleFor("version/user.md"), "models/user.go:User sha256:abc", []byte("# User\n"))
+	assert.True(t, strings.HasPrefix(string(content), "<!-- Code generated by structera; DO NOT EDIT. -->\n<!-- Source: models/user.go:User sha256:abc -->\n<!-- Checksum: sha256:"))
+
+	checksum, body, ok = splitHeader(content)
+	assert.True(t, ok)
+	assert.Len(t, checksum, 64)
+	assert.Equal(t, "# User\n", string(body))
+}
+
+func TestHandModified(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	generated := withHeader(goComment, "", []byte("package version\n"))
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "Untouched", content: string(generated), want: ""},
+		{name: "Edited body", content: string(generated) + "\nvar Edited = true\n", want: "its content no longer matches the checksum of its header"},
+		{name: "No header", content: "package version\n", want: "it has no generated code header"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tempDir, "file.go")
+			assert.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+
+			reason, err := handModified(path)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, reason)
+		})
+	}
+
+	reason, err := handModified(filepath.Join(tempDir, "missing.go"))
+	assert.NoError(t, err)
+	assert.Empty(t, reason)
+}
+
+func TestGenerator_VersionedStructs_HandModified(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	writeBatchFixture(t, tempDir)
+	fileName := filepath.Join(tempDir, "models", "user.go")
+	versionedDir := filepath.Join(tempDir, "models", string(ModuleFolder))
+	assert.NoError(t, NewGenerator(fileName, "User", "", string(ModuleFolder), false).VersionedStructs())
+
+	hub, err := os.ReadFile(filepath.Join(versionedDir, "user.go"))
+	assert.NoError(t, err)
+	assert.Regexp(t, `\n// Source: models/user.go:User sha256:[0-9a-f]{64}\n`, string(hub))
+
+	// Existing eras are only replaced when forced
+	v1Path := filepath.Join(versionedDir, "user", "v1.go")
+	v1, err := os.ReadFile(v1Path)
+	assert.NoError(t, err)
+	handEdited := string(v1) + "\nfunc (era V1) Custom() {}\n"
+	assert.NoError(t, os.WriteFile(v1Path, []byte(handEdited), 0644))
+	assert.NoError(t, NewGenerator(fileName, "User", "", string(ModuleFolder), false).VersionedStructs())
+
+	v1, err = os.ReadFile(v1Path)
+	assert.NoError(t, err)
+	assert.Equal(t, handEdited, string(v1))
+
+	// Forcing overwrites a hand-modified era along with the rest
+	source, err := os.ReadFile(fileName)
+	assert.NoError(t, err)
+	edited := strings.Replace(string(source), "\tName  string\n", "\tName  string\n\tAge   int\n", 1)
+	assert.NoError(t, os.WriteFile(fileName, []byte(edited), 0644))
+
+	assert.NoError(t, NewGenerator(fileName, "User", "", string(ModuleFolder), true).VersionedStructs())
+	v1, err = os.ReadFile(v1Path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(v1), "Custom")
+	assert.Regexp(t, `Age\s+int`, string(v1))
+
+	// Eras without a header may be written by hand, and only forcing regenerates them
+	_, preHeader, ok := splitHeader(v1)
+	assert.True(t, ok)
+	preHeader = append(preHeader, "\nfunc (era V1) Old() {}\n"...)
+	assert.NoError(t, os.WriteFile(v1Path, preHeader, 0644))
+	assert.NoError(t, NewGenerator(fileName, "User", "", string(ModuleFolder), false).VersionedStructs())
+	v1, err = os.ReadFile(v1Path)
+	assert.NoError(t, err)
+	assert.Equal(t, string(preHeader), string(v1))
+
+	assert.NoError(t, NewGenerator(fileName, "User", "", string(ModuleFolder), true).VersionedStructs())
+	v1, err = os.ReadFile(v1Path)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(v1), GeneratedHeader))
+	assert.NotContains(t, string(v1), "Old")
+
+	// A hub without a header is only overwritten when forced too
+	hubPath := filepath.Join(versionedDir, "user.go")
+	assert.NoError(t, os.WriteFile(hubPath, []byte("package version\n"), 0644))
+	err = NewGenerator(fileName, "User", "", string(ModuleFolder), false).VersionedStructs()
+	assert.ErrorContains(t, err, hubPath+" was modified by hand, it has no generated code header; regenerate with --force to overwrite it")
+	hub, err = os.ReadFile(hubPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "package version\n", string(hub))
+
+	assert.NoError(t, NewGenerator(fileName, "User", "", string(ModuleFolder), true).VersionedStructs())
+	hub, err = os.ReadFile(hubPath)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(hub), GeneratedHeader))
+
+	// A hand-modified hub is only overwritten when forced
+	assert.NoError(t, os.WriteFile(hubPath, append(hub, "\nfunc (hub User) Custom() {}\n"...), 0644))
+	err = NewGenerator(fileName, "User", "", string(ModuleFolder), false).VersionedStructs()
+	assert.ErrorContains(t, err, hubPath+" was modified by hand, its content no longer matches the checksum of its header; regenerate with --force to overwrite it")
+
+	assert.NoError(t, NewGenerator(fileName, "User", "", string(ModuleFolder), true).VersionedStructs())
+	hub, err = os.ReadFile(hubPath)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(hub), "Custom")
+}
@@ -0,0 +1,33 @@
+package helpers
+
+import "strings"
+
+// MarkdownCell escapes a value for a Markdown table cell, which can't hold
+// pipes nor line breaks.
+func MarkdownCell(value string) string {
+	value = strings.ReplaceAll(value, "|", `\|`)
+	return strings.Join(strings.Fields(value), " ")
+}
+
+// MarkdownCode wraps the value in an inline code span, fenced with more
+// backticks than the longest run of them in the value, so they show as they are.
+func MarkdownCode(value string) string {
+	longest, run := 0, 0
+	for _, r := range value {
+		if r != '`' {
+			run = 0
+			continue
+		}
+		run++
+		if run > longest {
+			longest = run
+		}
+	}
+
+	// A space keeps a backtick at either end from joining the fence
+	if strings.HasPrefix(value, "`") || strings.HasSuffix(value, "`") {
+		value = " " + value + " "
+	}
+	fence := strings.Repeat("`", longest+1)
+	return fence + value + fence
+}
@@ -0,0 +1,44 @@
+package helpers
+
+import "testing"
+
+func TestMarkdownCell(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"plain", "The name of the user", "The name of the user"},
+		{"pipes", "int | string", `int \| string`},
+		{"line breaks", "First line\nsecond  line", "First line second line"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MarkdownCell(tt.value); got != tt.want {
+				t.Errorf("MarkdownCell() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownCode(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"plain", "map[string]int", "`map[string]int`"},
+		{"backticks", "struct{ A int `json:\"a\"` }", "``struct{ A int `json:\"a\"` }``"},
+		{"backtick runs", "a``b", "```a``b```"},
+		{"backtick at the end", "tag `x`", "`` tag `x` ``"},
+		{"empty", "", "``"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MarkdownCode(tt.value); got != tt.want {
+				t.Errorf("MarkdownCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
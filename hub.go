@@ -2,8 +2,10 @@ package main
 
 import (
 	"fmt"
-	"os"
+	"github.com/stoewer/go-strcase"
+	"go/ast"
 	"path/filepath"
+	"strings"
 )
 
 type HubFieldInfo struct {
@@ -11,39 +13,102 @@ type HubFieldInfo struct {
 	FormattedName string
 	Type          string
 	Tag           string
+	Embedded      bool
+	Qualifiers    []string
+	Doc           string
+	Discriminator bool // Holds the version of the payload
+}
+
+// HubPointer reports whether the hub wraps the era field in a pointer, which
+// it does for every field but the embedded pointers.
+func (f HubFieldInfo) HubPointer() bool {
+	return !(f.Embedded && strings.HasPrefix(f.Type, "*"))
+}
+
+// Exported reports whether the hub package can copy the era field.
+func (f HubFieldInfo) Exported() bool {
+	return ast.IsExported(f.Name)
 }
 
 type VersionedHubTemplateData struct {
 	PackageName     string
 	ModulePackage   string
 	ImportPath      string
-	ExistingImports []string
+	Imports         []Import
 	StructName      StructName
 	Fields          []HubFieldInfo
 	VersionedFields map[int][]HubFieldInfo
 	Versions        []int
 	CustomType      bool
+	Discriminator   *HubFieldInfo
+	FieldTable      FieldTableData
 }
 
-func (g *Generator) HubFile(existingImports []string, importPath string) error {
+// FieldTableData is the field membership table of the hub, with a bit per
+// hub field set in the eras that have it, for detecting eras without reflection.
+type FieldTableData struct {
+	Name  string
+	Words int // Length of the bitsets
+	Eras  []EraBits
+}
+
+type EraBits struct {
+	Version int
+	Words   string // Go literal of the bitset words
+}
+
+func (g *Generator) HubFile(imports []Import, importPath string) error {
 	versionedDir := filepath.Join(g.OutputDir, g.Package)
-	if err := os.MkdirAll(versionedDir, os.ModePerm); err != nil {
-		return err
-	}
 
 	return g.FileFromTemplate(GenerateFileFromTemplateInput{
 		TemplateFilePath: "hub.go.tmpl",
 		OutputFilePath:   filepath.Join(versionedDir, fmt.Sprintf("%s.go", g.StructName.Snake)),
+		Source:           g.Source,
 		Data: VersionedHubTemplateData{
 			PackageName:     g.Package,
 			ModulePackage:   string(ModulePackage),
 			ImportPath:      importPath,
-			ExistingImports: existingImports,
+			Imports:         g.importsFor(imports, g.ProcessedFields, g.templateImports(importPath)),
 			StructName:      g.StructName,
 			VersionedFields: g.VersionedFields,
 			Fields:          g.ProcessedFields,
 			Versions:        g.Format.SortedVersions,
 			CustomType:      g.Format.CustomType,
+			Discriminator:   discriminator(g.ProcessedFields),
+			FieldTable:      g.fieldTable(),
 		},
 	})
 }
+
+func (g *Generator) fieldTable() FieldTableData {
+	table := FieldTableData{
+		Name:  strcase.LowerCamelCase(g.StructName.Original) + "FieldTable",
+		Words: (len(g.ProcessedFields) + 63) / 64,
+	}
+
+	for _, version := range g.Format.SortedVersions {
+		words := make([]uint64, table.Words)
+		for i, field := range g.ProcessedFields {
+			if _, ok := findField(g.VersionedFields[version], field.Name); ok {
+				words[i/64] |= 1 << (i % 64)
+			}
+		}
+
+		literals := make([]string, 0, len(words))
+		for _, word := range words {
+			literals = append(literals, fmt.Sprintf("%#x", word))
+		}
+		table.Eras = append(table.Eras, EraBits{Version: version, Words: strings.Join(literals, ", ")})
+	}
+	return table
+}
+
+// discriminator returns the field tagged version:"discriminator", if any.
+func discriminator(fields []HubFieldInfo) *HubFieldInfo {
+	for i := range fields {
+		if fields[i].Discriminator {
+			return &fields[i]
+		}
+	}
+	return nil
+}
@@ -1,10 +1,20 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"github.com/gerardforcada/structera/conversor"
+	"github.com/gerardforcada/structera/detector"
+	"github.com/gerardforcada/structera/example"
+	"github.com/gerardforcada/structera/example/version"
+	"github.com/gerardforcada/structera/example/version/order"
+	testingera "github.com/gerardforcada/structera/example/version/testing"
+	"github.com/gerardforcada/structera/example/version/user"
+	"github.com/gerardforcada/structera/interfaces"
 	"github.com/stretchr/testify/assert"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -29,7 +39,7 @@ func TestGenerator_HubFile(t *testing.T) {
 	tests := []struct {
 		name            string
 		fields          fields
-		existingImports []string
+		existingImports []Import
 		importPath      string
 		wantErr         bool
 		wantMatch       bool
@@ -154,14 +164,14 @@ func TestGenerator_HubFile(t *testing.T) {
 				},
 				Package: string(ModuleFolder),
 				ProcessedFields: []HubFieldInfo{
-					{FormattedName: "InEveryVersion", Type: "*string", Tag: "json:\"in_every_version\""},
-					{FormattedName: "OnlyIn1", Type: "       *int", Tag: "json:\"only_in_1\""},
-					{FormattedName: "From2ToEnd", Type: "    *uint8", Tag: "json:\"from_2_to_end\""},
-					{FormattedName: "FromStartTo3", Type: "  *[]byte", Tag: "json:\"from_start_to_3\""},
-					{FormattedName: "From1to4", Type: "      *float32", Tag: "json:\"from_1_to_4\""},
+					{Name: "InEveryVersion", FormattedName: "InEveryVersion", Type: "*string", Tag: "json:\"in_every_version\""},
+					{Name: "OnlyIn1", FormattedName: "OnlyIn1", Type: "       *int", Tag: "json:\"only_in_1\""},
+					{Name: "From2ToEnd", FormattedName: "From2ToEnd", Type: "    *uint8", Tag: "json:\"from_2_to_end\""},
+					{Name: "FromStartTo3", FormattedName: "FromStartTo3", Type: "  *[]byte", Tag: "json:\"from_start_to_3\""},
+					{Name: "From1to4", FormattedName: "From1to4", Type: "      *float32", Tag: "json:\"from_1_to_4\""},
 				},
 			},
-			existingImports: []string{},
+			existingImports: []Import{},
 			importPath:      "github.com/gerardforcada/structera/example",
 			wantErr:         false,
 			wantMatch:       true,
@@ -240,14 +250,14 @@ func TestGenerator_HubFile(t *testing.T) {
 				},
 				Package: string(ModuleFolder),
 				ProcessedFields: []HubFieldInfo{
-					{FormattedName: "InEveryVersion", Type: "*string"},
-					{FormattedName: "OnlyIn1", Type: "*int"},
-					{FormattedName: "FromStartTo3", Type: "[]byte"},
-					{FormattedName: "From1to4", Type: "*float32"},
-					{FormattedName: "From2ToEnd", Type: "*uint8"},
+					{Name: "InEveryVersion", FormattedName: "InEveryVersion", Type: "*string"},
+					{Name: "OnlyIn1", FormattedName: "OnlyIn1", Type: "*int"},
+					{Name: "FromStartTo3", FormattedName: "FromStartTo3", Type: "[]byte"},
+					{Name: "From1to4", FormattedName: "From1to4", Type: "*float32"},
+					{Name: "From2ToEnd", FormattedName: "From2ToEnd", Type: "*uint8"},
 				},
 			},
-			existingImports: []string{"test"},
+			existingImports: []Import{{Path: "test"}},
 			importPath:      "github.com/gerardforcada/structera/example",
 			wantErr:         false,
 			wantMatch:       false,
@@ -263,12 +273,20 @@ func TestGenerator_HubFile(t *testing.T) {
 				VersionedFields: tt.fields.VersionedFields,
 				Package:         tt.fields.Package,
 				ProcessedFields: tt.fields.ProcessedFields,
+				Filename:        "example/testing.go",
+				Resolver:        &Resolver{},
 			}
+			fileSet, _, structType, err := g.ParseStruct()
+			assert.NoError(t, err)
+			assert.NoError(t, g.fingerprint(fileSet, structType))
 
 			if err := g.HubFile(tt.existingImports, tt.importPath); (err != nil) != tt.wantErr {
 				t.Errorf("HubFile() error = %v, wantErr %v", err, tt.wantErr)
 			}
 
+			// Only the rendering is tested here, so the file is written without type-checking its package
+			assert.NoError(t, g.Output.Write())
+
 			// Path to the generated file
 			generatedFilePath := filepath.Join(tempDir, tt.fields.Package, fmt.Sprintf("%s.go", tt.fields.StructName.Lower))
 
@@ -292,3 +310,326 @@ func TestGenerator_HubFile(t *testing.T) {
 		})
 	}
 }
+
+func exampleUserHub() version.User {
+	name, level, ratio, status, skipped := "hey", uint8(3), float32(1.5), example.Status("active"), "not in JSON"
+	hub := version.User{}
+	hub.InEveryVersion = &name
+	hub.From2ToEnd = &level
+	hub.From1to4 = &ratio
+	hub.WorksWithMaps = &map[string]int64{"a": 1}
+	hub.AndSlices = &[]int{1, 2, 3}
+	hub.AndCustomTypes = &status
+	hub.AndSkippedInJSON = &skipped
+	return hub
+}
+
+// TestExample_ToEra runs the field by field copies generated for example/user.go
+func TestExample_ToEra(t *testing.T) {
+	hub := exampleUserHub()
+
+	var v3 user.V3
+	assert.NoError(t, hub.ToEra(&v3))
+	assert.Equal(t, user.V3{
+		InEveryVersion:   "hey",
+		From2ToEnd:       3,
+		From1to4:         1.5,
+		WorksWithMaps:    map[string]int64{"a": 1},
+		AndSlices:        []int{1, 2, 3},
+		AndCustomTypes:   "active",
+		AndSkippedInJSON: "not in JSON",
+	}, v3)
+
+	// The JSON round-trip loses the fields JSON skips
+	var viaJSON user.V3
+	assert.NoError(t, conversor.ToEra(&viaJSON, &hub))
+	assert.Equal(t, "", viaJSON.AndSkippedInJSON)
+	viaJSON.AndSkippedInJSON = v3.AndSkippedInJSON
+	assert.Equal(t, v3, viaJSON)
+
+	// Eras behind the interface keep their type
+	var era interfaces.Era = &user.V1{}
+	assert.NoError(t, hub.ToEra(&era))
+	assert.Equal(t, &user.V1{InEveryVersion: "hey", From1to4: 1.5, WorksWithMaps: map[string]int64{"a": 1}, AndSlices: []int{1, 2, 3}, AndSkippedInJSON: "not in JSON"}, era)
+
+	era = user.V5{}
+	assert.NoError(t, hub.ToEra(&era))
+	assert.Equal(t, "not in JSON", era.(user.V5).AndSkippedInJSON)
+
+	var nilEra *user.V2
+	assert.Error(t, hub.ToEra(nilEra))
+
+	// Other targets still go through JSON
+	fields := map[string]any{}
+	assert.NoError(t, hub.ToEra(&fields))
+	assert.Equal(t, "hey", fields["in_every_version"])
+
+	// Eras of the version are copied into the hub as they are
+	assert.NoError(t, hub.FillEra(&v3, 3))
+	assert.Equal(t, v3, hub.V3)
+	assert.NoError(t, hub.FillEra(v3, 2))
+	assert.Equal(t, "hey", hub.V2.InEveryVersion)
+	assert.Equal(t, "", hub.V2.AndSkippedInJSON)
+}
+
+// TestExample_FromEra goes era -> hub -> other era with the code generated for example/user.go
+func TestExample_FromEra(t *testing.T) {
+	hub := exampleUserHub()
+
+	v1 := user.V1{InEveryVersion: "hey", OnlyIn1: 1, From1to4: 1.5, AndSkippedInJSON: "not in JSON"}
+	assert.NoError(t, hub.FromEra(v1))
+	assert.Equal(t, 1, *hub.OnlyIn1)
+	assert.Equal(t, "not in JSON", *hub.AndSkippedInJSON)
+
+	// Only the fields of the era are set
+	assert.Nil(t, hub.From2ToEnd)
+	assert.Nil(t, hub.AndCustomTypes)
+	detected, err := hub.DetectVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, detected)
+
+	var v4 user.V4
+	assert.NoError(t, hub.ToEra(&v4))
+	assert.Equal(t, user.V4{InEveryVersion: "hey", From1to4: 1.5, AndSkippedInJSON: "not in JSON"}, v4)
+
+	// Pointer eras and eras behind the interface work the same
+	var era interfaces.Era = &user.V3{InEveryVersion: "pointer", From2ToEnd: 3}
+	assert.NoError(t, hub.FromEra(era))
+	assert.Equal(t, "pointer", *hub.InEveryVersion)
+	assert.Equal(t, uint8(3), *hub.From2ToEnd)
+	assert.Nil(t, hub.OnlyIn1)
+
+	var nilEra *user.V2
+	assert.Error(t, hub.FromEra(nilEra))
+	assert.Error(t, hub.FromEra(nil))
+	assert.Error(t, hub.FromEra(&testingera.V1{}))
+}
+
+// TestExample_Lost reports the fields of example/user.go a conversion drops
+func TestExample_Lost(t *testing.T) {
+	hub := exampleUserHub()
+	onlyIn1 := 7
+	hub.OnlyIn1 = &onlyIn1
+
+	var v5 user.V5
+	lost := conversor.Lost(&v5, &hub)
+	assert.Equal(t, &conversor.LossError{Target: "user.V5", Fields: []conversor.LostField{
+		{Name: "OnlyIn1", Key: "only_in_1"},
+		{Name: "From1to4", Key: "from_1_to_4"},
+	}}, lost)
+
+	// The strict conversion fails with the same report, without touching the era
+	assert.Equal(t, lost, conversor.ToEraStrict(&v5, &hub))
+	assert.Equal(t, user.V5{}, v5)
+
+	var v1 user.V1
+	assert.Equal(t, []conversor.LostField{
+		{Name: "From2ToEnd", Key: "from_2_to_end"},
+		{Name: "AndCustomTypes", Key: "AndCustomTypes"},
+	}, conversor.Lost(&v1, &hub).Fields)
+	hub.From2ToEnd, hub.AndCustomTypes = nil, nil
+	assert.NoError(t, conversor.ToEraStrict(&v1, &hub))
+	assert.Equal(t, 7, v1.OnlyIn1)
+}
+
+// TestExample_To converts the hub of example/user.go with the generic helpers
+func TestExample_To(t *testing.T) {
+	hub := exampleUserHub()
+
+	v3, err := conversor.To[user.V3](&hub)
+	assert.NoError(t, err)
+	assert.Equal(t, "not in JSON", v3.AndSkippedInJSON)
+
+	era, err := conversor.ToVersion(&hub, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(3), era.(user.V5).From2ToEnd)
+
+	_, err = conversor.ToVersion(&hub, 6)
+	assert.EqualError(t, err, "error converting hub version.User to version 6: unknown version 6")
+
+	// Without a concrete era to convert into, they fail instead of panicking
+	_, err = conversor.To[interfaces.Era](&hub)
+	var conversionErr *conversor.ConversionError
+	assert.ErrorAs(t, err, &conversionErr)
+	_, err = conversor.To[*user.V3](&hub)
+	assert.ErrorAs(t, err, &conversionErr)
+	var nilEra interfaces.Era
+	assert.Error(t, hub.ToEra(&nilEra))
+}
+
+// TestExample_FromJSON detects the eras of example/user.go from the keys of JSON objects
+func TestExample_FromJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    int
+		wantErr string
+	}{
+		{name: "Top-level keys", data: `{"in_every_version":"hey","only_in_5":5}`, want: 5},
+		{name: "Null keys", data: `{"from_start_to_3":null,"AndCustomTypes":null}`, want: 3},
+		{name: "Nested keys", data: `{"only_in_1":1,"AndStructs":{"Value":"nested"}}`, want: 1},
+		{name: "Unknown nested keys", data: `{"AndStructs":{"Other":"nested"}}`, wantErr: "no era has every key of the JSON, the closest one, version 1, lacks AndStructs.Other"},
+		{name: "Keys skipped by JSON", data: `{"AndSkippedInJSON":"hey"}`, wantErr: "no era has every key of the JSON, the closest one, version 1, lacks AndSkippedInJSON"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := detector.FromJSON([]byte(tt.data), &version.User{})
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestExample_Discriminator trusts the version example/order.go payloads carry
+func TestExample_Discriminator(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    int
+		wantErr string
+	}{
+		{name: "Discriminator over the fields", data: `{"schema_version":2,"id":"a","total":5}`, want: 2},
+		{name: "Fields without discriminator", data: `{"id":"a","total":5}`, want: 1},
+		{name: "Null discriminator", data: `{"schema_version":null,"currency":"EUR"}`, want: 3},
+		{name: "Unknown version", data: `{"schema_version":7}`, wantErr: "SchemaVersion holds an unknown version 7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hub version.Order
+			assert.NoError(t, json.Unmarshal([]byte(tt.data), &hub))
+
+			got, err := hub.DetectVersion()
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// Eras write their own version, whatever the field holds
+	data, err := json.Marshal(order.V3{SchemaVersion: 1, ID: "a"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"schema_version":3,"id":"a","total_cents":0,"currency":"","created_by":""}`, string(data))
+
+	next, err := order.V1{SchemaVersion: 1}.Upgrade()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, next.SchemaVersion)
+
+	hub := version.Order{}
+	assert.NoError(t, hub.FromEra(order.V2{}))
+	assert.Equal(t, 2, *hub.SchemaVersion)
+
+	v3, err := conversor.To[order.V3](&hub)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, v3.SchemaVersion)
+}
+
+// TestExample_Embedded keeps the fields embedded in example/order.go promoted in the hub and its eras
+func TestExample_Embedded(t *testing.T) {
+	data := `{"schema_version":2,"id":"a","total_cents":5,"created_by":"ana","address":"Main St"}`
+
+	var hub version.Order
+	assert.NoError(t, json.Unmarshal([]byte(data), &hub))
+	assert.Equal(t, "ana", hub.CreatedBy)
+	assert.Equal(t, "Main St", hub.Address)
+
+	v2, err := conversor.To[order.V2](&hub)
+	assert.NoError(t, err)
+	assert.Equal(t, order.V2{
+		SchemaVersion: 2,
+		ID:            "a",
+		TotalCents:    5,
+		Audit:         example.Audit{CreatedBy: "ana"},
+		Shipping:      &example.Shipping{Address: "Main St"},
+	}, v2)
+
+	encoded, err := json.Marshal(v2)
+	assert.NoError(t, err)
+	assert.JSONEq(t, data, string(encoded))
+
+	// V1 has no shipping, the address is left out
+	v1, err := v2.Downgrade()
+	assert.NoError(t, err)
+	encoded, err = json.Marshal(v1)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"schema_version":1,"id":"a","total":0,"created_by":"ana"}`, string(encoded))
+
+	assert.NoError(t, hub.FromEra(v1))
+	assert.Equal(t, "ana", hub.CreatedBy)
+	assert.Nil(t, hub.Shipping)
+	got, err := hub.DetectVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, got)
+}
+
+// TestExample_DetectVersion checks the generated field table of example/user.go against the reflective detection
+func TestExample_DetectVersion(t *testing.T) {
+	fields := []string{"InEveryVersion", "OnlyIn1", "From2ToEnd", "FromStartTo3", "From1to4", "OnlyIn5", "AndStructs", "AndCustomTypes"}
+	for mask := 0; mask < 1<<len(fields); mask++ {
+		hub := version.User{}
+		allFields := reflect.ValueOf(&hub.UserAllFields).Elem()
+		for i, name := range fields {
+			if mask&(1<<i) != 0 {
+				field := allFields.FieldByName(name)
+				field.Set(reflect.New(field.Type().Elem()))
+			}
+		}
+
+		want, wantErr := detector.Detect(&hub)
+		got, err := hub.DetectVersion()
+		assert.Equal(t, want, got, "fields %08b", mask)
+		assert.Equal(t, wantErr, err, "fields %08b", mask)
+	}
+}
+
+func BenchmarkExample_DetectVersion(b *testing.B) {
+	hub := exampleUserHub()
+
+	b.Run("FieldTable", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := hub.DetectVersion(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Reflection", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := detector.Detect(&hub); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkExample_ToEra(b *testing.B) {
+	hub := exampleUserHub()
+
+	b.Run("Generated", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var era user.V3
+			if err := hub.ToEra(&era); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("JSON", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var era user.V3
+			if err := conversor.ToEra(&era, &hub); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
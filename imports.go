@@ -0,0 +1,315 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+type Import struct {
+	Alias   string
+	Path    string
+	Package string
+}
+
+// Name is the name the import is referred to with in the importing file.
+// Without an alias nor a known package name it's guessed from the path,
+// skipping major version suffixes (example.com/lib/v2, gopkg.in/yaml.v3)
+// and the usual go- and -go affixes.
+func (i Import) Name() string {
+	if i.Alias != "" {
+		return i.Alias
+	}
+	if i.Package != "" {
+		return i.Package
+	}
+
+	name := path.Base(i.Path)
+	if majorVersion.MatchString(name) && path.Dir(i.Path) != "." {
+		name = path.Base(path.Dir(i.Path))
+	}
+	if index := strings.Index(name, ".v"); index > 0 {
+		name = name[:index]
+	}
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "go-"), "-go")
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+func fileImports(node *ast.File) []Import {
+	var imports []Import
+	for _, i := range node.Imports {
+		importPath, err := strconv.Unquote(i.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		alias := ""
+		if i.Name != nil {
+			alias = i.Name.Name
+		}
+		imports = append(imports, Import{Alias: alias, Path: importPath})
+	}
+	return imports
+}
+
+// templateImports are the packages the hub template always imports.
+func (g *Generator) templateImports(importPath string) []Import {
+	return []Import{
+		{Path: "fmt"},
+		{Path: "encoding/json"},
+		{Path: path.Join(string(ModulePackage), "conversor")},
+		{Path: path.Join(string(ModulePackage), "detector")},
+		{Path: path.Join(string(ModulePackage), "interfaces")},
+		{Path: path.Join(importPath, g.Package, g.StructName.Snake)},
+	}
+}
+
+// ResolveImports keeps the imports of the source file the field types refer
+// to. Imports clashing with the ones of the hub template are renamed, and
+// Format renders the field types with the new names.
+func (g *Generator) ResolveImports(node *ast.File, structType *ast.StructType, importPath string) ([]Import, error) {
+	used := selectorQualifiers(structType)
+
+	var imports []Import
+	for _, i := range fileImports(node) {
+		if i.Alias == "_" || i.Alias == "." {
+			continue
+		}
+		imports = append(imports, i)
+	}
+
+	// Guessing the package name from the path isn't always right, so the
+	// packages of the qualifiers left are looked up
+	if missing := missingQualifiers(used, imports); len(missing) > 0 {
+		for index, i := range imports {
+			if i.Alias != "" || used[i.Name()] {
+				continue
+			}
+			if pkg, err := build.Import(i.Path, filepath.Dir(g.Filename), 0); err == nil {
+				imports[index].Package = pkg.Name
+			}
+		}
+		if missing = missingQualifiers(used, imports); len(missing) > 0 {
+			return nil, fmt.Errorf("struct '%s' uses package %s, which isn't imported by '%s'", g.StructName.Original, strings.Join(missing, ", "), g.Filename)
+		}
+	}
+
+	reserved := map[string]string{}
+	for _, i := range g.templateImports(importPath) {
+		reserved[i.Name()] = i.Path
+	}
+
+	taken := map[string]bool{}
+	for name := range reserved {
+		taken[name] = true
+	}
+	for _, i := range imports {
+		taken[i.Name()] = true
+	}
+
+	g.Format.Renames = map[string]string{}
+	for index, i := range imports {
+		reservedPath, ok := reserved[i.Name()]
+		if !ok || reservedPath == i.Path {
+			continue
+		}
+
+		alias := freeName(i.Name(), taken)
+		taken[alias] = true
+		g.Format.Renames[i.Name()] = alias
+		imports[index].Alias = alias
+	}
+
+	return imports, nil
+}
+
+// importsFor returns the imports the fields refer to, the source package
+// included, leaving out the ones already in the template.
+func (g *Generator) importsFor(imports []Import, fields []HubFieldInfo, templateImports []Import) []Import {
+	used := map[string]bool{}
+	for _, field := range fields {
+		for _, qualifier := range field.Qualifiers {
+			used[qualifier] = true
+		}
+	}
+
+	if g.SourceImport != nil {
+		imports = append(imports, *g.SourceImport)
+	}
+
+	var needed []Import
+	for _, i := range imports {
+		if used[i.Name()] && !containsImport(templateImports, i) {
+			needed = append(needed, i)
+		}
+	}
+	return needed
+}
+
+func containsImport(imports []Import, i Import) bool {
+	for _, other := range imports {
+		if other.Path == i.Path && other.Name() == i.Name() {
+			return true
+		}
+	}
+	return false
+}
+
+// selectorQualifiers returns the package names used by the field types.
+// In a type, a selector is always a qualified identifier.
+func selectorQualifiers(structType *ast.StructType) map[string]bool {
+	qualifiers := map[string]bool{}
+	for _, field := range structType.Fields.List {
+		ast.Inspect(field.Type, func(node ast.Node) bool {
+			if selector, ok := node.(*ast.SelectorExpr); ok {
+				if ident, ok := selector.X.(*ast.Ident); ok {
+					qualifiers[ident.Name] = true
+				}
+			}
+			return true
+		})
+	}
+	return qualifiers
+}
+
+func missingQualifiers(used map[string]bool, imports []Import) []string {
+	var missing []string
+	for qualifier := range used {
+		found := false
+		for _, i := range imports {
+			if i.Name() == qualifier {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, qualifier)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+func freeName(name string, taken map[string]bool) string {
+	alias := name
+	for n := 2; taken[alias]; n++ {
+		alias = fmt.Sprintf("%s%d", name, n)
+	}
+	return alias
+}
+
+// sourceAlias picks the name the generated files give to the source package,
+// so it never shadows another import of the hub or the eras.
+func (g *Generator) sourceAlias(imports []Import, importPath string) string {
+	taken := map[string]bool{}
+	for _, i := range append(g.templateImports(importPath), imports...) {
+		taken[i.Name()] = true
+	}
+	return freeName(SourcePackageAlias, taken)
+}
+
+// resolveSourceImport imports the source package in the generated files when
+// a field uses a type declared next to the struct, as long as it doesn't
+// create an import cycle.
+func (g *Generator) resolveSourceImport(node *ast.File, importPath string) error {
+	g.SourceImport = nil
+	if !usesQualifier(g.ProcessedFields, g.Format.sourceAlias()) {
+		return nil
+	}
+
+	if node.Name.Name == "main" {
+		return fmt.Errorf("struct '%s' uses types declared in package main, which the generated code can't import; move them to another package", g.StructName.Original)
+	}
+
+	sourceDir, err := filepath.Abs(filepath.Dir(g.Filename))
+	if err != nil {
+		return err
+	}
+	sourcePath, err := g.sourcePackage()
+	if err != nil {
+		return err
+	}
+	hubPath := path.Join(importPath, g.Package)
+	eraPath := path.Join(hubPath, g.StructName.Snake)
+
+	if sourcePath == hubPath || sourcePath == eraPath {
+		return fmt.Errorf("import cycle: struct '%s' uses types of package %s, which is also its output package; choose another output directory", g.StructName.Original, sourcePath)
+	}
+
+	importer, err := importingFile(sourceDir, hubPath, eraPath)
+	if err != nil {
+		return err
+	}
+	if importer != "" {
+		return fmt.Errorf("import cycle: struct '%s' uses types of package %s, but %s imports the generated package back; move the types or stop importing the generated code there", g.StructName.Original, sourcePath, importer)
+	}
+
+	g.SourceImport = &Import{Alias: g.Format.sourceAlias(), Path: sourcePath}
+	return nil
+}
+
+func usesQualifier(fields []HubFieldInfo, qualifier string) bool {
+	for _, field := range fields {
+		for _, q := range field.Qualifiers {
+			if q == qualifier {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// importingFile returns the first Go file of the directory importing any of
+// the given paths, tests excluded.
+func importingFile(dir string, importPaths ...string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		node, err := parser.ParseFile(token.NewFileSet(), filepath.Join(dir, name), nil, parser.ImportsOnly)
+		if err != nil {
+			return "", err
+		}
+
+		for _, i := range fileImports(node) {
+			for _, importPath := range importPaths {
+				if i.Path == importPath {
+					return filepath.Join(dir, name), nil
+				}
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// sourcePackage returns the import path of the package declaring the struct.
+func (g *Generator) sourcePackage() (string, error) {
+	sourceDir, err := filepath.Abs(filepath.Dir(g.Filename))
+	if err != nil {
+		return "", err
+	}
+	relativePath, err := filepath.Rel(filepath.Dir(g.Resolver.GoModPath), sourceDir)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(g.Resolver.ImportPath, filepath.ToSlash(relativePath)), nil
+}
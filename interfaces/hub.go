@@ -3,10 +3,11 @@ package interfaces
 type Hub interface {
 	GetMinVersion() int
 	GetMaxVersion() int
-	DetectVersion() int
+	DetectVersion() (int, error)
 	GetEraFromVersion(int) (Era, error)
 	GetVersions() []int
 	GetVersionStructs() []Era
 	GetBaseStruct() any
 	ToEra(any) error
+	FromEra(Era) error
 }
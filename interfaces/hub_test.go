@@ -23,8 +23,8 @@ func (m *mockHub) GetMaxVersion() int {
 	return m.maxVersion
 }
 
-func (m *mockHub) DetectVersion() int {
-	return m.detectedVer
+func (m *mockHub) DetectVersion() (int, error) {
+	return m.detectedVer, nil
 }
 
 func (m *mockHub) GetEraFromVersion(version int) (Era, error) {
@@ -55,6 +55,10 @@ func (m *mockHub) ToEra(target any) error {
 	return nil
 }
 
+func (m *mockHub) FromEra(era Era) error {
+	return nil
+}
+
 func TestMockHub(t *testing.T) {
 	mockEra1 := mockEra{name: "test1", version: 1}
 	mockEra2 := mockEra{name: "test2", version: 2}
@@ -85,7 +89,7 @@ func TestMockHub(t *testing.T) {
 	})
 
 	t.Run("TestDetectVersion", func(t *testing.T) {
-		if got := mockHub1.DetectVersion(); got != mockHub1.detectedVer {
+		if got, err := mockHub1.DetectVersion(); err != nil || got != mockHub1.detectedVer {
 			t.Errorf("DetectVersion() = %v, want %v", got, mockHub1.detectedVer)
 		}
 	})
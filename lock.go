@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/pmezard/go-difflib/difflib"
+	"go/parser"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const LockFileName = "structera.lock"
+
+type LockField struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Tag      string `json:"tag,omitempty"`
+	Embedded bool   `json:"embedded,omitempty"`
+}
+
+func (f LockField) String() string {
+	line := f.Name + " " + f.Type
+	if f.Embedded {
+		line = f.Type
+	}
+	if f.Tag != "" {
+		line += " `" + f.Tag + "`"
+	}
+	return line
+}
+
+type LockedStruct struct {
+	Released []int               `json:"released,omitempty"`
+	Eras     map[int][]LockField `json:"eras"`
+}
+
+// Lock records the eras of every struct of a module, by the import path of
+// its package and its name. The released ones are frozen: the struct can't
+// change them anymore.
+type Lock struct {
+	Structs map[string]*LockedStruct `json:"structs"`
+}
+
+func ParseLock(content []byte) (*Lock, error) {
+	lock := &Lock{}
+	if err := json.Unmarshal(content, lock); err != nil {
+		return nil, err
+	}
+	if lock.Structs == nil {
+		lock.Structs = make(map[string]*LockedStruct)
+	}
+	return lock, nil
+}
+
+// LockEras records the eras of the struct in the lock file next to go.mod,
+// and fails when a change alters a released era. The lock is only kept once
+// it exists or a version is released.
+func (g *Generator) LockEras(imports []Import) error {
+	lockPath := filepath.Join(filepath.Dir(g.Resolver.GoModPath), LockFileName)
+
+	// Several structs of a batch share the lock, so the pending one has the latest entries
+	if g.Output == nil {
+		g.Output = &Output{}
+	}
+	content, pending := g.Output.Get(lockPath)
+	if !pending {
+		var err error
+		if content, err = os.ReadFile(lockPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if content == nil && g.Release == "" {
+		return nil
+	}
+
+	lock := &Lock{Structs: make(map[string]*LockedStruct)}
+	if content != nil {
+		var err error
+		if lock, err = ParseLock(content); err != nil {
+			return fmt.Errorf("error parsing %s: %v", lockPath, err)
+		}
+	}
+
+	// Keyed by package, so moving or renaming the file of the struct keeps its entry
+	sourcePackage, err := g.sourcePackage()
+	if err != nil {
+		return err
+	}
+	key := sourcePackage + "." + g.StructName.Original
+	locked := lock.Structs[key]
+	if locked == nil {
+		locked = &LockedStruct{}
+	}
+
+	released, err := g.releasedVersions(locked.Released)
+	if err != nil {
+		return err
+	}
+
+	eras := make(map[int][]LockField)
+	var frozen []string
+	for _, version := range g.Format.SortedVersions {
+		fields := g.lockFields(g.VersionedFields[version], imports)
+		if previous, ok := locked.Eras[version]; ok && released[version] {
+			if diff := eraDiff(version, previous, fields, lockPath, g.Filename); diff != "" {
+				frozen = append(frozen, diff)
+			}
+		}
+		eras[version] = fields
+	}
+
+	var versions []int
+	for version := range released {
+		if _, ok := eras[version]; !ok {
+			frozen = append(frozen, fmt.Sprintf("era V%d is released, but the struct no longer has it", version))
+		}
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+
+	if len(frozen) > 0 {
+		return fmt.Errorf("struct '%s' changes released eras frozen in %s, move the changes to a new version:\n%s", g.StructName.Original, lockPath, strings.Join(frozen, "\n"))
+	}
+
+	locked.Released = versions
+	locked.Eras = eras
+	lock.Structs[key] = locked
+
+	// Types like <-chan int are kept readable in the diffs of the lock
+	var encoded bytes.Buffer
+	encoder := json.NewEncoder(&encoded)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(lock); err != nil {
+		return err
+	}
+	content = encoded.Bytes()
+
+	if g.Check {
+		return g.CompareFile(lockPath, content)
+	}
+	g.Output.Add(lockPath, content)
+	return nil
+}
+
+// releasedVersions adds the versions of the release flag, written like a
+// version tag, to the ones the lock already has.
+func (g *Generator) releasedVersions(previous []int) (map[int]bool, error) {
+	released := make(map[int]bool)
+	for _, version := range previous {
+		released[version] = true
+	}
+	if g.Release == "" {
+		return released, nil
+	}
+
+	maxVersion := g.Format.SortedVersions[len(g.Format.SortedVersions)-1]
+	for _, part := range g.Format.SplitVersionTag(g.Release) {
+		// Only the eras the struct has can be released
+		if start, end, err := g.Format.ParseVersionRange(part); err == nil && (start > maxVersion || end > maxVersion) {
+			return nil, fmt.Errorf("release %s goes past the last era V%d of %s", part, maxVersion, g.StructName.Original)
+		}
+		if message := g.Format.validateVersionRange(part); message != "" {
+			return nil, fmt.Errorf("invalid release %q: %s", g.Release, message)
+		}
+	}
+	for _, version := range g.Format.ParseVersionTag(g.Release, maxVersion) {
+		released[version] = true
+	}
+	return released, nil
+}
+
+func (g *Generator) lockFields(fields []HubFieldInfo, imports []Import) []LockField {
+	lockFields := []LockField{}
+	for _, field := range fields {
+		lockFields = append(lockFields, LockField{
+			Name:     field.Name,
+			Type:     g.lockType(field.Type, imports),
+			Tag:      field.Tag,
+			Embedded: field.Embedded,
+		})
+	}
+	return lockFields
+}
+
+// lockType renders the field type with the import paths of its packages
+// (time.Time, example.com/models.Status) instead of the names the generated
+// files give them, so renaming or aliasing an import doesn't change an era.
+func (g *Generator) lockType(fieldType string, imports []Import) string {
+	expr, err := parser.ParseExpr(fieldType)
+	if err != nil {
+		return fieldType
+	}
+
+	paths := make(map[string]string)
+	for _, i := range imports {
+		paths[i.Name()] = i.Path
+	}
+	if g.SourceImport != nil {
+		paths[g.SourceImport.Name()] = g.SourceImport.Path
+	}
+
+	format := &Format{Renames: paths}
+	return format.FieldType(expr, false)
+}
+
+// eraDiff returns the unified diff between the locked era and the one the
+// struct generates now, or nothing when they are the same.
+func eraDiff(version int, locked, current []LockField, lockPath, sourcePath string) string {
+	lines := func(fields []LockField) []string {
+		var lines []string
+		for _, field := range fields {
+			lines = append(lines, field.String()+"\n")
+		}
+		return lines
+	}
+
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        lines(locked),
+		B:        lines(current),
+		FromFile: fmt.Sprintf("V%d (%s)", version, lockPath),
+		ToFile:   fmt.Sprintf("V%d (%s)", version, sourcePath),
+		Context:  3,
+	})
+	return diff
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerator_LockEras(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	writeBatchFixture(t, tempDir)
+	fileName := filepath.Join(tempDir, "models", "user.go")
+	lockPath := filepath.Join(tempDir, LockFileName)
+	// Forced, so the eras follow the edits to the source
+	generate := func(release string, check bool) (*Generator, error) {
+		g := NewGenerator(fileName, "User", "", string(ModuleFolder), true)
+		g.Release = release
+		g.Check = check
+		return g, g.VersionedStructs()
+	}
+	editSource := func(old, new string) {
+		source, err := os.ReadFile(fileName)
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(fileName, []byte(strings.Replace(string(source), old, new, 1)), 0644))
+	}
+	readLock := func() *Lock {
+		content, err := os.ReadFile(lockPath)
+		assert.NoError(t, err)
+		lock, err := ParseLock(content)
+		assert.NoError(t, err)
+		return lock
+	}
+
+	// Without releases there is no lock
+	_, err = generate("", false)
+	assert.NoError(t, err)
+	assert.NoFileExists(t, lockPath)
+
+	// Releasing records every era and freezes the released ones
+	_, err = generate("1-2", false)
+	assert.NoError(t, err)
+	locked := readLock().Structs["example.com/batch/models.User"]
+	assert.Equal(t, []int{1, 2}, locked.Released)
+	assert.Equal(t, []LockField{{Name: "Name", Type: "string"}}, locked.Eras[1])
+	assert.Equal(t, []LockField{{Name: "Name", Type: "string"}, {Name: "Email", Type: "string"}}, locked.Eras[2])
+	assert.Len(t, locked.Eras, 3)
+
+	// The lock is up to date
+	g, err := generate("", true)
+	assert.NoError(t, err)
+	assert.Empty(t, g.StaleFiles)
+
+	// Unreleased eras are free to change
+	editSource("\tEmail string `version:\"2-3\"`\n", "\tEmail string `version:\"2-3\"`\n\tPhone string `version:\"3\"`\n")
+	_, err = generate("", false)
+	assert.NoError(t, err)
+	assert.Len(t, readLock().Structs["example.com/batch/models.User"].Eras[3], 3)
+
+	// A change to a released era fails with its diff, and nothing is written
+	hubPath := filepath.Join(tempDir, "models", string(ModuleFolder), "user.go")
+	hubBefore, err := os.ReadFile(hubPath)
+	assert.NoError(t, err)
+	lockBefore, err := os.ReadFile(lockPath)
+	assert.NoError(t, err)
+
+	editSource("`version:\"2-3\"`", "`version:\"2-3\" json:\"email\"`")
+	_, err = generate("", false)
+	assert.ErrorContains(t, err, "struct 'User' changes released eras frozen in "+lockPath)
+	assert.ErrorContains(t, err, "-Email string\n+Email string `json:\"email\"`\n")
+	assert.NotContains(t, err.Error(), "V1 (")
+
+	hubAfter, err := os.ReadFile(hubPath)
+	assert.NoError(t, err)
+	assert.Equal(t, string(hubBefore), string(hubAfter))
+	lockAfter, err := os.ReadFile(lockPath)
+	assert.NoError(t, err)
+	assert.Equal(t, string(lockBefore), string(lockAfter))
+
+	// Releases are written like version tags, and can't go past the last era
+	_, err = generate("7", false)
+	assert.EqualError(t, err, "release 7 goes past the last era V3 of User")
+	_, err = generate("2-9", false)
+	assert.EqualError(t, err, "release 2-9 goes past the last era V3 of User")
+	_, err = generate("3-1", false)
+	assert.ErrorContains(t, err, "invalid release \"3-1\"")
+}
+
+func TestGenerator_LockEras_ImportNames(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	modelsDir := filepath.Join(tempDir, "models")
+	fileName := filepath.Join(modelsDir, "user.go")
+	assert.NoError(t, os.MkdirAll(modelsDir, os.ModePerm))
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte(goMod(t, "example.com/lock")), 0644))
+	writeSource := func(imports, timeType string) {
+		source := "package models\n\n" + imports + "\n\ntype Status string\n\ntype User struct {\n\tName    string\n\tStatus  Status `version:\"2\"`\n\tCreated " + timeType + "\n}\n"
+		assert.NoError(t, os.WriteFile(fileName, []byte(source), 0644))
+	}
+	generate := func(release string) error {
+		g := NewGenerator(fileName, "User", "", string(ModuleFolder), true)
+		g.Release = release
+		return g.VersionedStructs()
+	}
+
+	writeSource(`import "time"`, "time.Time")
+	assert.NoError(t, generate("1-2"))
+	content, err := os.ReadFile(filepath.Join(tempDir, LockFileName))
+	assert.NoError(t, err)
+	lock, err := ParseLock(content)
+	assert.NoError(t, err)
+	assert.Equal(t, []LockField{
+		{Name: "Name", Type: "string"},
+		{Name: "Status", Type: "example.com/lock/models.Status"},
+		{Name: "Created", Type: "time.Time"},
+	}, lock.Structs["example.com/lock/models.User"].Eras[2])
+
+	// Aliasing the import renames the qualifier, not the type
+	writeSource(`import clock "time"`, "clock.Time")
+	assert.NoError(t, generate(""))
+
+	// Another package of the same name is a change
+	writeSource(`import time "example.com/lock/models/time"`, "time.Time")
+	assert.NoError(t, os.MkdirAll(filepath.Join(modelsDir, "time"), os.ModePerm))
+	assert.NoError(t, os.WriteFile(filepath.Join(modelsDir, "time", "time.go"), []byte("package time\n\ntype Time int64\n"), 0644))
+	assert.ErrorContains(t, generate(""), "+Created example.com/lock/models/time.Time\n")
+}
+
+func TestGenerator_LockEras_MovedSource(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	writeBatchFixture(t, tempDir)
+	fileName := filepath.Join(tempDir, "models", "user.go")
+	g := NewGenerator(fileName, "User", "", string(ModuleFolder), true)
+	g.Release = "1-2"
+	assert.NoError(t, g.VersionedStructs())
+
+	// The struct keeps its entry in another file of the package
+	movedName := filepath.Join(tempDir, "models", "people.go")
+	assert.NoError(t, os.Rename(fileName, movedName))
+	assert.NoError(t, NewGenerator(movedName, "User", "", string(ModuleFolder), true).VersionedStructs())
+
+	content, err := os.ReadFile(filepath.Join(tempDir, LockFileName))
+	assert.NoError(t, err)
+	lock, err := ParseLock(content)
+	assert.NoError(t, err)
+	assert.Len(t, lock.Structs, 1)
+	assert.Equal(t, []int{1, 2}, lock.Structs["example.com/batch/models.User"].Released)
+
+	// So its released eras are still frozen
+	source, err := os.ReadFile(movedName)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(movedName, []byte(strings.Replace(string(source), "\tName  string\n", "\tName  int\n", 1)), 0644))
+	err = NewGenerator(movedName, "User", "", string(ModuleFolder), true).VersionedStructs()
+	assert.ErrorContains(t, err, "struct 'User' changes released eras frozen in ")
+	assert.ErrorContains(t, err, "+Name int\n")
+}
+
+func TestGenerator_LockEras_Escaping(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	modelsDir := filepath.Join(tempDir, "models")
+	fileName := filepath.Join(modelsDir, "user.go")
+	assert.NoError(t, os.MkdirAll(modelsDir, os.ModePerm))
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte(goMod(t, "example.com/lock")), 0644))
+	source := "package models\n\ntype User struct {\n\tName   string\n\tEvents <-chan int `version:\"2\"`\n\tScores map[string][]chan<- int `version:\"2\"`\n}\n"
+	assert.NoError(t, os.WriteFile(fileName, []byte(source), 0644))
+
+	g := NewGenerator(fileName, "User", "", string(ModuleFolder), true)
+	g.Release = "1"
+	assert.NoError(t, g.VersionedStructs())
+
+	// Types are written as they are in the source, without HTML escapes
+	content, err := os.ReadFile(filepath.Join(tempDir, LockFileName))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), `"type": "<-chan int"`)
+	assert.Contains(t, string(content), `"type": "map[string][]chan<- int"`)
+	assert.NotContains(t, string(content), `\u003c`)
+	assert.True(t, strings.HasSuffix(string(content), "}\n"))
+
+	lock, err := ParseLock(content)
+	assert.NoError(t, err)
+	assert.Equal(t, LockField{Name: "Events", Type: "<-chan int"}, lock.Structs["example.com/lock/models.User"].Eras[2][1])
+}
+
+func TestParseLock(t *testing.T) {
+	lock, err := ParseLock([]byte(`{"structs": {"example.com/models.User": {"released": [1], "eras": {"1": [{"name": "Name", "type": "string", "tag": "json:\"name\""}]}}}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, &LockedStruct{
+		Released: []int{1},
+		Eras:     map[int][]LockField{1: {{Name: "Name", Type: "string", Tag: `json:"name"`}}},
+	}, lock.Structs["example.com/models.User"])
+
+	lock, err = ParseLock([]byte(`{}`))
+	assert.NoError(t, err)
+	assert.NotNil(t, lock.Structs)
+
+	_, err = ParseLock([]byte(`{`))
+	assert.Error(t, err)
+}
@@ -3,10 +3,7 @@ package main
 import (
 	"flag"
 	"fmt"
-	"github.com/stoewer/go-strcase"
 	"os"
-	"path/filepath"
-	"strings"
 )
 
 type Module string
@@ -17,6 +14,11 @@ const (
 	ModulePackage Module = "github.com/gerardforcada/structera"
 )
 
+const (
+	CommandCheck  = "check"
+	CommandCompat = "compat"
+)
+
 func main() {
 	if err := cli(flag.CommandLine); err != nil {
 		_, err = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -32,9 +34,15 @@ func cli(flagset *flag.FlagSet) error {
 		fileName    string
 		structName  string
 		outputDir   string
+		configPath  string
+		release     string
+		from        int
+		to          int
 		showVersion bool
 		showHelp    bool
 		force       bool
+		jsonOutput  bool
+		html        bool
 	)
 
 	// Define both long and short flag versions
@@ -47,6 +55,18 @@ func cli(flagset *flag.FlagSet) error {
 	flagset.StringVar(&outputDir, "output", "", "Output directory (optional)")
 	flagset.StringVar(&outputDir, "o", "", "Output directory (optional) (shorthand)")
 
+	flagset.StringVar(&configPath, "config", "", "Path to the structera.yaml config file (optional)")
+	flagset.StringVar(&configPath, "c", "", "Path to the structera.yaml config file (optional) (shorthand)")
+
+	flagset.StringVar(&release, "release", "", "Versions to freeze in structera.lock, written like a version tag (optional)")
+	flagset.StringVar(&release, "r", "", "Versions to freeze in structera.lock, written like a version tag (optional) (shorthand)")
+
+	flagset.IntVar(&from, "from", 0, "Era to compare from, the one before --to by default (compat)")
+	flagset.IntVar(&to, "to", 0, "Era to compare to, the last one by default (compat)")
+	flagset.BoolVar(&jsonOutput, "json", false, "Print the compatibility report as JSON (compat)")
+
+	flagset.BoolVar(&html, "html", false, "Also document the versions of each struct in HTML (optional)")
+
 	flagset.BoolVar(&showHelp, "help", false, "Print the help page and exit")
 	flagset.BoolVar(&showHelp, "h", false, "Print the help page and exit (shorthand)")
 
@@ -56,7 +76,15 @@ func cli(flagset *flag.FlagSet) error {
 	flagset.BoolVar(&force, "force", false, "Replace existing versioned struct files")
 	flagset.BoolVar(&force, "F", false, "Replace existing versioned struct files (shorthand)")
 
-	err := flagset.Parse(os.Args[1:])
+	// An optional subcommand goes before the flags
+	args := os.Args[1:]
+	command := ""
+	if len(args) > 0 && (args[0] == CommandCheck || args[0] == CommandCompat) {
+		command, args = args[0], args[1:]
+	}
+	check := command == CommandCheck
+
+	err := flagset.Parse(args)
 	if err != nil {
 		return err
 	}
@@ -66,6 +94,61 @@ func cli(flagset *flag.FlagSet) error {
 		return nil
 	}
 
+	if command == CommandCompat && !showHelp {
+		if structName == "" {
+			return fmt.Errorf("missing the struct to compare, pass it with -s")
+		}
+		return compat(fileName, structName, configPath, from, to, jsonOutput)
+	}
+
+	// Positional package patterns switch to batch mode
+	if patterns := flagset.Args(); len(patterns) > 0 && fileName == "" && structName == "" && !showHelp {
+		batch := Batch{
+			Patterns:  patterns,
+			OutputDir: outputDir,
+			Package:   string(ModuleFolder),
+			Replace:   force,
+			Check:     check,
+			Release:   release,
+			HTML:      html,
+		}
+		staleFiles, err := batch.Run()
+		if err != nil {
+			return err
+		}
+
+		return generated(check, staleFiles)
+	}
+
+	// Without a struct or patterns, generate every model declared in the config file
+	if fileName == "" && structName == "" && !showHelp {
+		resolver := Resolver{ConfigPath: configPath}
+		if configPath != "" || resolver.FindConfigPath(".") == nil {
+			// The config declares where each model goes and what it releases, while -F and --html apply to every model
+			if outputDir != "" {
+				return fmt.Errorf("--output doesn't apply to the models of %s, set output in it instead", ConfigFileName)
+			}
+			if release != "" {
+				return fmt.Errorf("--release doesn't apply to the models of %s, set release on each model to release instead", ConfigFileName)
+			}
+			config, err := LoadConfig(resolver.ConfigPath)
+			if err != nil {
+				return err
+			}
+			targets, err := config.Targets(force)
+			if err != nil {
+				return err
+			}
+			batch := Batch{Check: check, HTML: html}
+			staleFiles, err := batch.Generate(targets)
+			if err != nil {
+				return err
+			}
+
+			return generated(check, staleFiles)
+		}
+	}
+
 	// Check if the required flags are set
 	if fileName == "" || structName == "" || showHelp {
 		fmt.Printf("Structera version %s\n\n", ModuleVersion)
@@ -74,18 +157,38 @@ func cli(flagset *flag.FlagSet) error {
 		fmt.Println("Usage:")
 		fmt.Println("  structera -f <path-to-struct-file> -s <StructName> [-o <output-directory>]")
 		fmt.Println("  structera --file <path-to-struct-file> --struct <StructName> [--output <output-directory>]")
+		fmt.Println("  structera [-o <output-directory>] [-F] <package-pattern>...")
+		fmt.Println("  structera [-c <path-to-structera.yaml>] [-F]")
+		fmt.Println("  structera check [-f <path-to-struct-file> -s <StructName> | <package-pattern>...]")
+		fmt.Println("  structera compat -s <StructName> [-f <path-to-struct-file>] [--from <version>] [--to <version>] [--json]")
+		fmt.Println("\nCommands:")
+		fmt.Println("  check          Exit with an error and print a diff when generated files are out of date")
+		fmt.Println("  compat         Classify the changes between two eras and exit with an error when they break JSON consumers")
 		fmt.Println("\nOptions:")
+		fmt.Println("  --config,  -c  (Optional) Path to the structera.yaml file, found in the parent directories by default")
 		fmt.Println("  --file,    -f  Path to the Go file containing the struct")
-		fmt.Println("  --force,   -F  Replace existing versioned struct files")
+		fmt.Println("  --force,   -F  Replace existing eras, and generated files even if modified by hand, naming them in a warning")
+		fmt.Println("  --from         (Optional) Era to compare from with compat, the one before --to by default")
+		fmt.Println("  --html         (Optional) Document the versions of each struct in HTML too, next to the Markdown one")
+		fmt.Println("  --json         (Optional) Print the compat report as JSON")
+		fmt.Println("  --release, -r  (Optional) Versions of a single struct to freeze in structera.lock, like \"1-3\" or \"4\", set per model in structera.yaml")
 		fmt.Println("  --struct,  -s  Name of the struct to version")
 		fmt.Println("  --output,  -o  (Optional) Output directory for the versioned struct files")
+		fmt.Println("  --to           (Optional) Era to compare to with compat, the last one by default")
 		fmt.Println("  --help,    -h  Prints this page and exit")
 		fmt.Println("  --version, -v  Print the version of Structera and exit")
+		fmt.Println("\nWith structera.yaml, --force and --html apply to every model on top of its options, while --output and --release")
+		fmt.Println("are refused: they are set per model in the config file.")
 		fmt.Println("\nExample:")
 		fmt.Println("  structera -f ./models/user.go -s User")
 		fmt.Println("  structera -f ./models/user.go -s User -o ./models/versioned")
 		fmt.Println("  structera --file ./models/user.go --struct User")
 		fmt.Println("  structera --file ./models/user.go --struct User --output ./models/versioned")
+		fmt.Println("  structera ./models/...")
+		fmt.Println("  structera check ./models/...")
+		fmt.Println("  structera -f ./models/user.go -s User --release 1-3")
+		fmt.Println("  structera compat -s User --from 3 --to 4 --json")
+		fmt.Println("  structera")
 		fmt.Println()
 
 		if showHelp {
@@ -94,28 +197,58 @@ func cli(flagset *flag.FlagSet) error {
 		return fmt.Errorf("missing required flags")
 	}
 
-	if outputDir == "" {
-		outputDir = filepath.Dir(fileName)
+	generator := NewGenerator(fileName, structName, outputDir, string(ModuleFolder), force)
+	generator.Check = check
+	generator.Release = release
+	generator.HTML = html
+	if err := generator.VersionedStructs(); err != nil {
+		return err
 	}
 
-	generator := Generator{
-		Format:   &Format{},
-		Resolver: &Resolver{},
-		Filename: fileName,
-		StructName: StructName{
-			Original: structName,
-			Lower:    strings.ToLower(structName),
-			Snake:    strcase.SnakeCase(structName),
-		},
-		OutputDir: outputDir,
-		Package:   string(ModuleFolder),
-		Replace:   force,
+	return generated(check, generator.StaleFiles)
+}
+
+// compat prints the compatibility report between two eras of the struct, and
+// fails when a change breaks JSON consumers in either direction.
+func compat(fileName, structName, configPath string, from, to int, jsonOutput bool) error {
+	if fileName == "" {
+		var err error
+		if fileName, err = FindStruct(structName, configPath); err != nil {
+			return err
+		}
 	}
 
-	if err := generator.VersionedStructs(); err != nil {
+	generator := NewGenerator(fileName, structName, "", string(ModuleFolder), false)
+	report, err := generator.Compat(from, to)
+	if err != nil {
 		return err
 	}
 
+	if jsonOutput {
+		content, err := report.JSON()
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(content))
+	} else {
+		fmt.Print(report)
+	}
+
+	if report.Breaking() {
+		return fmt.Errorf("V%d -> V%d of struct '%s' breaks JSON consumers (backward: %s, forward: %s)", report.From, report.To, structName, report.Backward, report.Forward)
+	}
+	return nil
+}
+
+func generated(check bool, staleFiles []StaleFile) error {
+	if check {
+		if err := StaleFilesError(staleFiles); err != nil {
+			return err
+		}
+		fmt.Println("Versioned structs are up to date.")
+		return nil
+	}
+
 	fmt.Println("Versioned structs generated successfully.")
 	return nil
 }
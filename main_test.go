@@ -26,6 +26,16 @@ func TestCliFunction(t *testing.T) {
 		{[]string{"-f", "example/user.go", "-s", "User", "-F", "--help"}, false},
 		{[]string{"-f", "example/user.go", "-s", "User", "--force", "--help", "--version"}, false},
 		{[]string{"-f", "example/user.go", "-s", "User", "-F", "--help", "--version"}, false},
+		{[]string{"example/..."}, false},
+		{[]string{"-F", "example/..."}, false},
+		{[]string{"check", "example/..."}, false},
+		{[]string{"check", "-f", "example/user.go", "-s", "User"}, false},
+		{[]string{"compat", "-s", "User", "--from", "4", "--to", "5"}, false},
+		{[]string{"compat", "-f", "example/user.go", "-s", "User", "--json"}, false},
+		{[]string{"compat", "-s", "User", "--to", "9"}, true},
+		{[]string{"compat"}, true},
+		{[]string{"-c", "structera.yaml", "-o", "generated"}, true},
+		{[]string{"-c", "structera.yaml", "-r", "1"}, true},
 	}
 
 	for _, tc := range testCases {
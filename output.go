@@ -0,0 +1,484 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type OutputFile struct {
+	Path    string
+	Content []byte
+}
+
+// Output holds the generated files in memory until every one of them is
+// rendered and their packages type-check, so a failed run writes nothing.
+type Output struct {
+	Files []OutputFile
+}
+
+func (o *Output) Add(path string, content []byte) {
+	for i := range o.Files {
+		if o.Files[i].Path == path {
+			o.Files[i].Content = content
+			return
+		}
+	}
+	o.Files = append(o.Files, OutputFile{Path: path, Content: content})
+}
+
+// Get returns the pending content of the file, if any.
+func (o *Output) Get(path string) ([]byte, bool) {
+	for _, file := range o.Files {
+		if file.Path == path {
+			return file.Content, true
+		}
+	}
+	return nil, false
+}
+
+// Flush type-checks the packages of the pending files and moves them all into place.
+func (o *Output) Flush() error {
+	if len(o.Files) == 0 {
+		return nil
+	}
+
+	if err := o.TypeCheck(); err != nil {
+		return err
+	}
+	if err := o.Write(); err != nil {
+		return err
+	}
+
+	o.Files = nil
+	return nil
+}
+
+// TypeCheck checks every package with pending files, as they will be once
+// written: the pending files plus the other Go files already in the directory.
+func (o *Output) TypeCheck() error {
+	outputImporter, err := o.newImporter()
+	if err != nil {
+		return err
+	}
+
+	importPaths := make([]string, 0, len(outputImporter.packages))
+	for importPath := range outputImporter.packages {
+		importPaths = append(importPaths, importPath)
+	}
+	sort.Strings(importPaths)
+
+	for _, importPath := range importPaths {
+		if _, err := outputImporter.Import(importPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write writes every file to a temporary file next to it, and only renames
+// them into place once all of them are written. The files it replaces are
+// moved aside until the last rename, and put back if any rename fails.
+func (o *Output) Write() error {
+	var createdDirs, tempFiles []string
+	var renamed []renamedFile
+	cleanup := func() {
+		// Undo the renames, the latest first, before removing the new directories
+		for i := len(renamed) - 1; i >= 0; i-- {
+			renamed[i].restore()
+		}
+		for _, tempFile := range tempFiles {
+			_ = os.Remove(tempFile)
+		}
+		for _, dir := range createdDirs {
+			_ = os.RemoveAll(dir)
+		}
+	}
+
+	for _, file := range o.Files {
+		dir := filepath.Dir(file.Path)
+		if created := missingDir(dir); created != "" {
+			createdDirs = append(createdDirs, created)
+		}
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			cleanup()
+			return err
+		}
+
+		tempFile, err := writeTempFile(dir, filepath.Base(file.Path), file.Content)
+		if tempFile != "" {
+			tempFiles = append(tempFiles, tempFile)
+		}
+		if err != nil {
+			cleanup()
+			return err
+		}
+	}
+
+	for i, file := range o.Files {
+		backup, err := moveAside(file.Path)
+		if err != nil {
+			cleanup()
+			return err
+		}
+		if err := os.Rename(tempFiles[i], file.Path); err != nil {
+			if backup != "" {
+				_ = os.Rename(backup, file.Path)
+			}
+			cleanup()
+			return err
+		}
+		renamed = append(renamed, renamedFile{path: file.Path, backup: backup})
+	}
+
+	for _, file := range renamed {
+		if file.backup != "" {
+			_ = os.Remove(file.backup)
+		}
+	}
+	return nil
+}
+
+// renamedFile is a file Write moved into place, with the original it replaced.
+type renamedFile struct {
+	path   string
+	backup string // Empty when the file is new
+}
+
+func (r renamedFile) restore() {
+	if r.backup == "" {
+		_ = os.Remove(r.path)
+		return
+	}
+	_ = os.Rename(r.backup, r.path)
+}
+
+// moveAside renames the file to a backup next to it, and returns the backup
+// path, or an empty one when there is no file to keep.
+func moveAside(path string) (string, error) {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) || (err == nil && info.IsDir()) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	backup, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.bak")
+	if err != nil {
+		return "", err
+	}
+	if err := backup.Close(); err != nil {
+		_ = os.Remove(backup.Name())
+		return "", err
+	}
+	if err := os.Rename(path, backup.Name()); err != nil {
+		_ = os.Remove(backup.Name())
+		return "", err
+	}
+	return backup.Name(), nil
+}
+
+func writeTempFile(dir, name string, content []byte) (string, error) {
+	tempFile, err := os.CreateTemp(dir, "."+name+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := tempFile.Write(content); err != nil {
+		_ = tempFile.Close()
+		return tempFile.Name(), err
+	}
+	if err := tempFile.Close(); err != nil {
+		return tempFile.Name(), err
+	}
+	return tempFile.Name(), os.Chmod(tempFile.Name(), 0644)
+}
+
+// missingDir returns the topmost directory of the path that doesn't exist yet.
+func missingDir(dir string) string {
+	missing := ""
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return missing
+		}
+		missing = dir
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return missing
+		}
+		dir = parent
+	}
+}
+
+// existingDir returns the closest directory of the path that exists.
+func existingDir(dir string) string {
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// outputImporter type-checks the pending packages from memory, and imports
+// everything else from source, resolved from the module of the pending package.
+type outputImporter struct {
+	fileSet   *token.FileSet
+	files     map[string][]OutputFile // import path -> pending files
+	packages  map[string]string       // import path -> directory
+	checked   map[string]*types.Package
+	listed    map[string]*listedPackage
+	moduleDir string
+}
+
+// listedPackage is the part of the go list output the importer needs.
+type listedPackage struct {
+	ImportPath string
+	Dir        string
+	GoFiles    []string
+	CgoFiles   []string
+	Error      *struct {
+		Err string
+	}
+}
+
+// newSourceImporter imports packages from source only, resolved from the
+// module of the directory.
+func newSourceImporter(dir string) *outputImporter {
+	return &outputImporter{
+		fileSet:   token.NewFileSet(),
+		files:     make(map[string][]OutputFile),
+		packages:  make(map[string]string),
+		checked:   make(map[string]*types.Package),
+		listed:    make(map[string]*listedPackage),
+		moduleDir: dir,
+	}
+}
+
+func (o *Output) newImporter() (*outputImporter, error) {
+	i := newSourceImporter("")
+
+	for _, file := range o.Files {
+		if filepath.Ext(file.Path) != ".go" {
+			continue
+		}
+
+		dir, err := filepath.Abs(filepath.Dir(file.Path))
+		if err != nil {
+			return nil, err
+		}
+		importPath, err := dirImportPath(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		i.files[importPath] = append(i.files[importPath], file)
+		i.packages[importPath] = dir
+	}
+	return i, nil
+}
+
+func (i *outputImporter) Import(importPath string) (*types.Package, error) {
+	return i.ImportFrom(importPath, "", 0)
+}
+
+func (i *outputImporter) ImportFrom(importPath, dir string, mode types.ImportMode) (*types.Package, error) {
+	if pkg, ok := i.checked[importPath]; ok {
+		return pkg, nil
+	}
+
+	pkgDir, pending := i.packages[importPath]
+	if !pending {
+		return i.importSource(importPath, dir)
+	}
+
+	files, err := i.parse(importPath, pkgDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// The packages it imports are resolved from its own module, all at once
+	moduleDir := i.moduleDir
+	i.moduleDir = existingDir(pkgDir)
+	defer func() { i.moduleDir = moduleDir }()
+
+	var imports []string
+	for _, file := range files {
+		for _, spec := range file.Imports {
+			if path, err := strconv.Unquote(spec.Path.Value); err == nil && i.packages[path] == "" && i.listed[path] == nil {
+				imports = append(imports, path)
+			}
+		}
+	}
+	if err := i.list(imports...); err != nil {
+		return nil, err
+	}
+
+	var typeErrors []string
+	config := types.Config{
+		Importer: i,
+		Error: func(err error) {
+			typeErrors = append(typeErrors, err.Error())
+		},
+	}
+	pkg, _ := config.Check(importPath, i.fileSet, files, nil)
+	if len(typeErrors) > 0 {
+		message := fmt.Sprintf("generated package %s doesn't compile:\n  %s", importPath, strings.Join(typeErrors, "\n  "))
+		if strings.Contains(message, "could not import "+string(ModulePackage)) {
+			message += fmt.Sprintf("\nthe module needs %s, add it with: go get %s", ModulePackage, ModulePackage)
+		}
+		return nil, fmt.Errorf("%s", message)
+	}
+
+	i.checked[importPath] = pkg
+	return pkg, nil
+}
+
+// importSource type-checks a package the generated code depends on. Only
+// its declarations matter, so function bodies and errors are skipped.
+func (i *outputImporter) importSource(importPath, dir string) (*types.Package, error) {
+	if importPath == "unsafe" {
+		return types.Unsafe, nil
+	}
+
+	if i.listed[importPath] == nil {
+		if err := i.list(importPath); err != nil {
+			return nil, err
+		}
+	}
+	listed := i.listed[importPath]
+	if listed == nil || listed.Dir == "" {
+		if listed != nil && listed.Error != nil {
+			return nil, fmt.Errorf("%s", listed.Error.Err)
+		}
+		return nil, fmt.Errorf("package %s not found", importPath)
+	}
+	if pkg, ok := i.checked[listed.ImportPath]; ok {
+		return pkg, nil
+	}
+
+	var files []*ast.File
+	for _, name := range append(listed.GoFiles, listed.CgoFiles...) {
+		node, err := parser.ParseFile(i.fileSet, filepath.Join(listed.Dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, node)
+	}
+
+	config := types.Config{
+		Importer:         i,
+		FakeImportC:      true,
+		IgnoreFuncBodies: true,
+		Error:            func(error) {},
+	}
+	pkg, _ := config.Check(listed.ImportPath, i.fileSet, files, nil)
+	i.checked[importPath] = pkg
+	return pkg, nil
+}
+
+// list runs go list once for the packages and all their dependencies, from
+// the module being checked.
+func (i *outputImporter) list(importPaths ...string) error {
+	if len(importPaths) == 0 {
+		return nil
+	}
+
+	command := exec.Command("go", append([]string{"list", "-e", "-deps", "-json", "--"}, importPaths...)...)
+	command.Dir = i.moduleDir
+	var stdout, stderr bytes.Buffer
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+	if err := command.Run(); err != nil {
+		return fmt.Errorf("error listing the packages imported by the generated code: %v\n%s", err, stderr.String())
+	}
+
+	decoder := json.NewDecoder(&stdout)
+	for decoder.More() {
+		listed := &listedPackage{}
+		if err := decoder.Decode(listed); err != nil {
+			return err
+		}
+		i.listed[listed.ImportPath] = listed
+	}
+	return nil
+}
+
+// parse returns the pending files of the package, plus the Go files already
+// in its directory that the run doesn't replace.
+func (i *outputImporter) parse(importPath, dir string) ([]*ast.File, error) {
+	pending := make(map[string]bool)
+	var files []*ast.File
+	for _, file := range i.files[importPath] {
+		node, err := parser.ParseFile(i.fileSet, file.Path, file.Content, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, node)
+
+		absPath, err := filepath.Abs(file.Path)
+		if err != nil {
+			return nil, err
+		}
+		pending[absPath] = true
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		filePath := filepath.Join(dir, name)
+		if entry.IsDir() || filepath.Ext(name) != ".go" || strings.HasSuffix(name, "_test.go") || pending[filePath] {
+			continue
+		}
+		if match, err := build.Default.MatchFile(dir, name); err != nil || !match {
+			continue
+		}
+
+		node, err := parser.ParseFile(i.fileSet, filePath, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, node)
+	}
+
+	return files, nil
+}
+
+// dirImportPath returns the import path of a directory, based on the closest go.mod.
+func dirImportPath(dir string) (string, error) {
+	resolver := Resolver{}
+	if err := resolver.FindGoModPath(dir); err != nil {
+		return "", fmt.Errorf("error finding go.mod: %v", err)
+	}
+	if err := resolver.GetBaseImportPath(); err != nil {
+		return "", fmt.Errorf("error getting base import path: %v", err)
+	}
+
+	relativePath, err := filepath.Rel(filepath.Dir(resolver.GoModPath), dir)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(resolver.ImportPath, filepath.ToSlash(relativePath)), nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// goMod returns a go.mod requiring this module from the working tree, so the
+// code generated for the fixtures type-checks.
+func goMod(t *testing.T, module string) string {
+	repoDir, err := os.Getwd()
+	assert.NoError(t, err)
+	return fmt.Sprintf("module %s\n\ngo 1.18\n\nrequire %s v0.0.0\n\nreplace %s => %s\n", module, ModulePackage, ModulePackage, repoDir)
+}
+
+func TestOutput_Flush(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name: "Packages that type-check are written",
+			files: map[string]string{
+				"out/a.go":     "package out\n\nimport \"example.com/output/out/sub\"\n\nvar A = sub.B\n",
+				"out/sub/b.go": "package sub\n\nimport \"time\"\n\nvar B = time.Second\n",
+			},
+		},
+		{
+			name: "Type errors write nothing",
+			files: map[string]string{
+				"out/a.go":     "package out\n\nimport \"example.com/output/out/sub\"\n\nvar A = sub.B\n",
+				"out/sub/b.go": "package sub\n\nvar B string = 1\n",
+			},
+			wantErr: "generated package example.com/output/out/sub doesn't compile",
+		},
+		{
+			name: "Unknown imports write nothing",
+			files: map[string]string{
+				"out/a.go": "package out\n\nimport \"example.com/output/missing\"\n\nvar A = missing.B\n",
+			},
+			wantErr: "could not import example.com/output/missing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir, err := os.MkdirTemp("", "test")
+			assert.NoError(t, err)
+			defer func(path string) {
+				err := os.RemoveAll(path)
+				assert.NoError(t, err)
+			}(tempDir)
+			assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte(goMod(t, "example.com/output")), 0644))
+
+			output := &Output{}
+			for name, content := range tt.files {
+				output.Add(filepath.Join(tempDir, name), []byte(content))
+			}
+
+			err = output.Flush()
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.NoDirExists(t, filepath.Join(tempDir, "out"))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Empty(t, output.Files)
+
+			for name, content := range tt.files {
+				written, err := os.ReadFile(filepath.Join(tempDir, name))
+				assert.NoError(t, err)
+				assert.Equal(t, content, string(written))
+			}
+
+			// No temporary file is left behind
+			entries, err := os.ReadDir(filepath.Join(tempDir, "out"))
+			assert.NoError(t, err)
+			assert.Len(t, entries, 2)
+		})
+	}
+}
+
+func TestOutput_Write_RenameFailure(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	outDir := filepath.Join(tempDir, "out")
+	assert.NoError(t, os.MkdirAll(filepath.Join(outDir, "taken"), os.ModePerm))
+	assert.NoError(t, os.WriteFile(filepath.Join(outDir, "taken", "keep.go"), []byte("package taken\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(outDir, "a.go"), []byte("package out // original\n"), 0644))
+
+	// The last file can't replace a directory, after the others are renamed into place
+	output := &Output{}
+	output.Add(filepath.Join(outDir, "a.go"), []byte("package out\n"))
+	output.Add(filepath.Join(outDir, "b.go"), []byte("package out\n"))
+	output.Add(filepath.Join(outDir, "sub", "c.go"), []byte("package sub\n"))
+	output.Add(filepath.Join(outDir, "taken"), []byte("package out\n"))
+	assert.Error(t, output.Write())
+
+	// Everything is as it was before the run
+	original, err := os.ReadFile(filepath.Join(outDir, "a.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package out // original\n", string(original))
+	assert.NoFileExists(t, filepath.Join(outDir, "b.go"))
+	assert.NoDirExists(t, filepath.Join(outDir, "sub"))
+	assert.FileExists(t, filepath.Join(outDir, "taken", "keep.go"))
+
+	entries, err := os.ReadDir(outDir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestOutput_TypeCheck_ExistingFiles(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte(goMod(t, "example.com/output")), 0644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(tempDir, "out"), os.ModePerm))
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "out", "existing.go"), []byte("package out\n\nvar Existing = 1\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "out", "replaced.go"), []byte("package out\n\nvar Replaced = undefined\n"), 0644))
+
+	// The files on disk are part of the package, except the ones the run replaces
+	output := &Output{}
+	output.Add(filepath.Join(tempDir, "out", "replaced.go"), []byte("package out\n\nvar Replaced = Existing\n"))
+	assert.NoError(t, output.TypeCheck())
+
+	output.Add(filepath.Join(tempDir, "out", "new.go"), []byte("package out\n\nvar Existing = 2\n"))
+	assert.ErrorContains(t, output.TypeCheck(), "Existing redeclared")
+}
+
+func TestGenerator_FileFromTemplate_FormatError(t *testing.T) {
+	g := &Generator{}
+	err := g.FileFromTemplate(GenerateFileFromTemplateInput{
+		TemplateFilePath: "era.go.tmpl",
+		OutputFilePath:   "broken.go",
+		Data: VersionedEraTemplateData{
+			StructName:    StructName{Original: "User", Snake: "user"},
+			Fields:        []HubFieldInfo{{Name: "Name", FormattedName: "Name", Type: "map[string"}},
+			VersionNumber: 1,
+		},
+	})
+	assert.ErrorContains(t, err, "error formatting broken.go")
+	assert.Nil(t, g.Output)
+}
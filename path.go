@@ -12,21 +12,45 @@ import (
 type Resolver struct {
 	ImportPath string
 	GoModPath  string
+	ConfigPath string
 }
 
 func (r *Resolver) FindGoModPath(startDir string) error {
-	currentDir := startDir
+	goModPath, err := findUp(startDir, "go.mod")
+	if err != nil {
+		return err
+	}
+
+	r.GoModPath = goModPath
+	return nil
+}
+
+func (r *Resolver) FindConfigPath(startDir string) error {
+	configPath, err := findUp(startDir, ConfigFileName)
+	if err != nil {
+		return err
+	}
+
+	r.ConfigPath = configPath
+	return nil
+}
+
+func findUp(startDir string, fileName string) (string, error) {
+	currentDir, err := filepath.Abs(startDir)
+	if err != nil {
+		return "", err
+	}
+
 	for {
-		// Check if go.mod exists in the current directory
-		if _, err := os.Stat(filepath.Join(currentDir, "go.mod")); err == nil {
-			r.GoModPath = filepath.Join(currentDir, "go.mod")
-			return nil
+		// Check if the file exists in the current directory
+		if _, err := os.Stat(filepath.Join(currentDir, fileName)); err == nil {
+			return filepath.Join(currentDir, fileName), nil
 		}
 
 		// Move up to the parent directory
 		parentDir := filepath.Dir(currentDir)
 		if parentDir == currentDir {
-			return fmt.Errorf("go.mod not found")
+			return "", fmt.Errorf("%s not found", fileName)
 		}
 		currentDir = parentDir
 	}
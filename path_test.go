@@ -72,3 +72,26 @@ func TestResolver_GetBaseImportPath_Failure(t *testing.T) {
 	err = resolver.GetBaseImportPath()
 	assert.Error(t, err)
 }
+
+func TestResolver_FindConfigPath(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "test")
+	assert.NoError(t, err)
+	defer func(path string) {
+		err := os.RemoveAll(path)
+		assert.NoError(t, err)
+	}(tempDir)
+
+	nestedDir := filepath.Join(tempDir, "models", "user")
+	assert.NoError(t, os.MkdirAll(nestedDir, os.ModePerm))
+
+	resolver := Resolver{}
+	assert.Error(t, resolver.FindConfigPath(nestedDir))
+
+	configPath := filepath.Join(tempDir, ConfigFileName)
+	err = os.WriteFile(configPath, []byte("models: []\n"), 0644)
+	assert.NoError(t, err)
+
+	err = resolver.FindConfigPath(nestedDir)
+	assert.NoError(t, err)
+	assert.Equal(t, configPath, resolver.ConfigPath)
+}
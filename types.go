@@ -15,16 +15,19 @@ type VersionedTypesTemplateData struct {
 
 func (g *Generator) TypesFile(importPath string) error {
 	versionedDir := filepath.Join(g.OutputDir, g.Package)
-	if err := os.MkdirAll(versionedDir, os.ModePerm); err != nil {
-		return err
-	}
 
-	files, err := os.ReadDir(filepath.Join(g.OutputDir, g.Package))
-	if err != nil {
+	files, err := os.ReadDir(versionedDir)
+	if err != nil && !os.IsNotExist(err) {
 		return err
 	}
 
+	// Hubs generated in this run are registered even if they are not on disk yet
 	fileNameMap := make(map[string]string)
+	for _, hub := range append([]StructName{g.StructName}, g.Hubs...) {
+		if hub.Snake != "" {
+			fileNameMap[strcase.UpperCamelCase(hub.Snake)] = hub.Snake
+		}
+	}
 	for _, file := range files {
 		if !file.IsDir() {
 			fileName := file.Name()